@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/intellectronica/skillz/skillz-go/skillz"
+)
+
+// runAudit implements `skillz audit verify [--audit-log path | path]`.
+func runAudit(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "verify" {
+		return fmt.Errorf("usage: skillz audit verify [--audit-log path | path]")
+	}
+	flags := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	auditLog := flags.String("audit-log", os.Getenv("SKILLZ_AUDIT_LOG"), "Audit log to verify (env SKILLZ_AUDIT_LOG)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	path := *auditLog
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+	if path == "" {
+		return fmt.Errorf("audit verify requires an audit log path")
+	}
+
+	verification, err := skillz.VerifyAuditLog(path)
+	if err != nil {
+		return fmt.Errorf("audit log %s failed verification after %d records: %w", path, verification.Records, err)
+	}
+	fmt.Fprintf(stdout, "OK: %d records verified, last hash %s\n", verification.Records, verification.LastHash)
+	return nil
+}
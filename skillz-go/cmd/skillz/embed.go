@@ -0,0 +1,19 @@
+//go:build skillz_embed
+
+package main
+
+import (
+	"embed"
+
+	"github.com/intellectronica/skillz/skillz-go/skillz"
+)
+
+// embeddedFS holds the skills copied into ./embedded, e.g. by go generate.
+// The directory holds only a .keep placeholder until then.
+//
+//go:embed all:embedded
+var embeddedFS embed.FS
+
+func embeddedSkills() (skillz.Source, error) {
+	return skillz.NewEmbeddedSource(embeddedFS, "embedded")
+}
@@ -0,0 +1,19 @@
+//go:build !skillz_embed
+
+package main
+
+import "github.com/intellectronica/skillz/skillz-go/skillz"
+
+// To build a self-contained binary, copy a skills directory into ./embedded
+// and build with the skillz_embed tag:
+//
+//	SKILLZ_EMBED_DIR=~/.skillz go generate ./cmd/skillz
+//	go build -tags skillz_embed ./cmd/skillz
+//
+//go:generate go run ./internal/embedskills -from $SKILLZ_EMBED_DIR -to embedded
+
+// embeddedSkills returns the skills compiled into the binary, or nil when it
+// was built without the skillz_embed tag.
+func embeddedSkills() (skillz.Source, error) {
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+// Command embedskills copies a skills directory to where the skillz_embed
+// build of cmd/skillz embeds it from. It is run by go generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	from := flag.String("from", "", "Skills directory to embed")
+	to := flag.String("to", "embedded", "Directory embedded by the skillz_embed build")
+	flag.Parse()
+	if err := copySkills(*from, *to); err != nil {
+		fmt.Fprintln(os.Stderr, "embedskills:", err)
+		os.Exit(1)
+	}
+}
+
+// placeholder keeps the embedded directory in version control, so the
+// skillz_embed build compiles before go generate has run. Discovery ignores
+// it.
+const placeholder = ".keep"
+
+// copySkills replaces to with a copy of from, keeping the placeholder.
+func copySkills(from string, to string) error {
+	if from == "" {
+		return fmt.Errorf("-from is required; set SKILLZ_EMBED_DIR when running go generate")
+	}
+	stat, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%s is not a directory", from)
+	}
+	if err := os.RemoveAll(to); err != nil {
+		return err
+	}
+	if err := os.CopyFS(to, os.DirFS(from)); err != nil {
+		return err
+	}
+	keep := filepath.Join(to, placeholder)
+	if _, err := os.Stat(keep); err == nil {
+		return nil
+	}
+	return os.WriteFile(keep, nil, 0o644)
+}
@@ -2,39 +2,154 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/intellectronica/skillz/skillz-go/internal/skillz"
+	"github.com/intellectronica/skillz/skillz-go/skillz"
 )
 
 func main() {
 	home, _ := os.UserHomeDir()
 	defaultRoot := filepath.Join(home, ".skillz")
 
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		if err := runStats(os.Args[2:], defaultRoot, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := runAudit(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	listSkills := flag.Bool("list-skills", false, "List parsed skills and exit")
 	fetchResource := flag.String("fetch-resource", "", "Fetch a resource by URI and print JSON")
 	transport := flag.String("transport", "stdio", "Transport: stdio, http, sse")
 	host := flag.String("host", "127.0.0.1", "Host for HTTP/SSE transport")
 	port := flag.Int("port", 8000, "Port for HTTP/SSE transport")
 	path := flag.String("path", "/mcp", "Path for HTTP/SSE transport")
+	maxPayloadBytes := flag.Int64("max-payload-bytes", 1<<20, "Maximum bytes of resource content returned by a single fetch or read")
+	authTokens := flag.String("auth-tokens", os.Getenv("SKILLZ_AUTH_TOKENS"), "Comma-separated identity=token bearer tokens for HTTP/SSE (env SKILLZ_AUTH_TOKENS)")
+	apiKeysFile := flag.String("api-keys-file", "", "File of 'identity sha256-hex [groups]' hashed API keys for HTTP/SSE")
+	jwksFile := flag.String("jwks-file", "", "Local JWKS file used to validate bearer JWTs for HTTP/SSE")
+	jwtIssuer := flag.String("jwt-issuer", "", "Required JWT issuer (iss)")
+	jwtAudience := flag.String("jwt-audience", "", "Required JWT audience (aud)")
+	listen := flag.String("listen", "", "Listen address for HTTP/SSE overriding --host/--port, e.g. unix:///run/skillz.sock")
+	socketMode := flag.String("socket-mode", "0600", "File permissions (octal) for a Unix socket listener")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file for HTTP/SSE (reloaded on SIGHUP)")
+	tlsKey := flag.String("tls-key", "", "TLS private key file for HTTP/SSE")
+	clientCA := flag.String("client-ca", "", "CA bundle used to require and verify client certificates (mutual TLS); requires --tls-cert and --tls-key")
+	drainTimeout := flag.Duration("drain-timeout", 10*time.Second, "Time allowed for in-flight requests to finish after SIGTERM/SIGINT")
+	metricsEnabled := flag.Bool("metrics", false, "Expose Prometheus metrics at /metrics on the HTTP/SSE listener")
+	metricsAddress := flag.String("metrics-address", "", "Serve Prometheus metrics on a separate admin address, e.g. 127.0.0.1:9090")
+	policyFile := flag.String("policy-file", "", "YAML authorization policy mapping identities and groups to visible skills")
+	lazy := flag.Bool("lazy", false, "Parse only front matter at startup and load skill bodies and resources on demand")
+	usageLogPath := flag.String("usage-log", os.Getenv("SKILLZ_USAGE_LOG"), "Append tool calls and resource reads to this JSONL usage log for `skillz stats` (env SKILLZ_USAGE_LOG)")
+	sourceOptions := addSourceFlags(flag.CommandLine)
+	useIndex := flag.Bool("index", false, "Cache parsed skills between runs under --cache-dir so unchanged skills are not parsed again (always on for the stdio transport unless --no-index is set)")
+	noIndex := flag.Bool("no-index", false, "Do not cache parsed skills between runs for the stdio transport")
+	indexFile := flag.String("index-file", "", "File caching parsed skills between runs; implies --index")
+	refreshInterval := flag.Duration("refresh-interval", 5*time.Minute, "How often to refresh remote sources while serving (0 disables)")
+	auditLogPath := flag.String("audit-log", os.Getenv("SKILLZ_AUDIT_LOG"), "Record every disclosed resource in this hash-chained JSONL audit log (env SKILLZ_AUDIT_LOG)")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "Export traces to this OTLP/HTTP collector, e.g. http://localhost:4318 (env OTEL_EXPORTER_OTLP_ENDPOINT)")
+	serviceName := flag.String("service-name", "skillz", "Service name reported with exported traces")
+	verbose := flag.Bool("verbose", false, "Enable debug logging (same as --log-level debug)")
+	logLevel := flag.String("log-level", "warn", "Log level: debug, info, warn, error")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
+	logToTmp := flag.Bool("log", false, "Write logs to /tmp/skillz.log instead of stderr")
+	logFile := flag.String("log-file", "", "Write logs to this file instead of stderr")
+	logMaxSize := flag.Int64("log-max-size-mb", 10, "Rotate the log file once it exceeds this many megabytes (0 disables rotation)")
+	logMaxBackups := flag.Int("log-max-backups", 3, "Number of rotated log files to keep")
 	flag.Parse()
 
-	skillsRoot := defaultRoot
-	args := flag.Args()
-	if len(args) > 0 && args[0] != "" {
-		skillsRoot = args[0]
+	logOptions := skillz.LogOptions{
+		Level:        *logLevel,
+		Format:       *logFormat,
+		File:         *logFile,
+		MaxSizeBytes: *logMaxSize << 20,
+		MaxBackups:   *logMaxBackups,
+	}
+	if *verbose {
+		logOptions.Level = "debug"
+	}
+	if *logToTmp && logOptions.File == "" {
+		logOptions.File = filepath.Join(os.TempDir(), "skillz.log")
+	}
+	logger, logCloser, err := skillz.NewLogger(logOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer logCloser.Close()
+
+	sources, remoteSources, err := sourceOptions.build()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	skillsRoot := skillsRootFor(flag.Args(), defaultRoot, sources)
+
+	var tracer *skillz.Tracer
+	if *otlpEndpoint != "" {
+		tracer, err = skillz.NewTracer(skillz.TracerOptions{Endpoint: *otlpEndpoint, ServiceName: *serviceName})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracer.Shutdown(shutdownCtx); err != nil {
+				logger.Warn("failed to export remaining spans", "error", err)
+			}
+		}()
 	}
 
 	registry := skillz.NewRegistry(skillsRoot)
+	registry.Lazy = *lazy
+	registry.Logger = logger
+	registry.Tracer = tracer
+	registry.Sources = sources
+	// A stdio server is started for every agent session, so it benefits most
+	// from not parsing unchanged skills again.
+	if *useIndex || *indexFile != "" || (*transport == "stdio" && !*noIndex) {
+		registry.IndexPath = *indexFile
+		if registry.IndexPath == "" {
+			registry.IndexPath = discoveryIndexPath(*sourceOptions.cacheDir, skillsRoot)
+		}
+	}
+	_, _ = skillz.SyncSources(context.Background(), registry)
 	if err := registry.Load(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
+	var auditLog *skillz.AuditLog
+	if *auditLogPath != "" {
+		auditLog, err = skillz.OpenAuditLog(*auditLogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+	}
+
 	if *listSkills {
 		skills := registry.Skills()
 		if len(skills) == 0 {
@@ -42,13 +157,34 @@ func main() {
 			return
 		}
 		for _, item := range skills {
-			fmt.Printf("- %s (slug: %s) -> %s\n", item.Metadata.Name, item.Slug, item.Directory)
+			location := item.Directory
+			if !item.IsLocal() && item.IsZip() {
+				location = item.ZipPath
+			}
+			line := fmt.Sprintf("- %s (slug: %s) -> %s", item.Metadata.Name, item.Slug, location)
+			if !item.IsLocal() {
+				origin := item.Origin
+				if item.Revision != "" {
+					origin += " " + item.Revision
+				}
+				line += " [" + origin + "]"
+			}
+			if *lazy {
+				line += fmt.Sprintf(" [front matter %s]", item.MetadataLoadTime)
+			}
+			fmt.Println(line)
 		}
 		return
 	}
 
 	if *fetchResource != "" {
-		result := skillz.FetchResourceJSON(registry, *fetchResource)
+		result := skillz.FetchResourceJSONWithOptions(registry, *fetchResource, skillz.FetchOptions{MaxBytes: *maxPayloadBytes})
+		if auditLog != nil {
+			if err := auditLog.RecordFetch(context.Background(), *fetchResource, result); err != nil {
+				fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+				os.Exit(1)
+			}
+		}
 		encoded, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "failed to marshal result: %v\n", err)
@@ -58,16 +194,223 @@ func main() {
 		return
 	}
 
-	mcpServer := skillz.BuildMCPServer(registry)
+	authenticators, err := buildAuthenticators(*authTokens, *apiKeysFile, *jwksFile, *jwtIssuer, *jwtAudience)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *clientCA != "" {
+		authenticators = append(authenticators, skillz.ClientCertificateAuthenticator{})
+	}
+
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --socket-mode %q: %v\n", *socketMode, err)
+		os.Exit(1)
+	}
+
+	serverOptions := skillz.ServerOptions{MaxPayloadBytes: *maxPayloadBytes, Audit: auditLog, Tracer: tracer, Logger: logger}
+	if *policyFile != "" {
+		policy, err := skillz.LoadPolicy(*policyFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		serverOptions.Policy = policy
+	}
+
+	if *usageLogPath != "" {
+		usageLog, err := skillz.OpenUsageLog(*usageLogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer usageLog.Close()
+		serverOptions.Usage = usageLog
+	}
+
+	var metrics *skillz.Metrics
+	if *metricsEnabled || *metricsAddress != "" {
+		metrics = skillz.NewMetrics(registry)
+		serverOptions.Metrics = metrics
+	}
+
+	mcpServer := skillz.BuildMCPServer(registry, serverOptions)
 	runOptions := skillz.RunOptions{
-		Transport: *transport,
-		Host:      *host,
-		Port:      *port,
-		Path:      *path,
+		Transport:      *transport,
+		Host:           *host,
+		Port:           *port,
+		Path:           *path,
+		Listen:         *listen,
+		SocketMode:     os.FileMode(mode),
+		DrainTimeout:   *drainTimeout,
+		Registry:       registry,
+		Authenticators: authenticators,
+		Tracer:         tracer,
+		Logger:         logger,
+		TLS: skillz.TLSOptions{
+			CertFile:     *tlsCert,
+			KeyFile:      *tlsKey,
+			ClientCAFile: *clientCA,
+		},
+	}
+
+	if *metricsEnabled {
+		runOptions.Metrics = metrics
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *metricsAddress != "" {
+		go func() {
+			if err := skillz.ServeMetrics(ctx, *metricsAddress, metrics); err != nil {
+				logger.Error("metrics server stopped", "error", err)
+			}
+		}()
 	}
 
-	if err := skillz.RunMCPServer(context.Background(), mcpServer, runOptions); err != nil {
+	if remoteSources > 0 && *refreshInterval > 0 {
+		go skillz.WatchSources(ctx, registry, *refreshInterval, func(previous []skillz.Skill) {
+			skillz.RefreshSkills(mcpServer, registry, previous, serverOptions)
+		})
+	}
+
+	if err := skillz.RunMCPServer(ctx, mcpServer, runOptions); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
+
+func defaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "skillz")
+	}
+	return filepath.Join(os.TempDir(), "skillz-cache")
+}
+
+// discoveryIndexPath names the default discovery index of a skills root, so
+// servers for different roots do not evict each other's entries.
+func discoveryIndexPath(cacheDir string, root string) string {
+	if absolute, err := filepath.Abs(root); err == nil && root != "" {
+		root = absolute
+	}
+	digest := sha256.Sum256([]byte(root))
+	return filepath.Join(cacheDir, "index", hex.EncodeToString(digest[:8])+".json")
+}
+
+// sourceFlags select the skill sources loaded alongside the skills root. The
+// server and `skillz stats` share them so both see the same skills.
+type sourceFlags struct {
+	catalogs  *string
+	gitRepo   *string
+	gitRef    *string
+	gitCommit *string
+	gitSubdir *string
+	cacheDir  *string
+}
+
+func addSourceFlags(flags *flag.FlagSet) sourceFlags {
+	return sourceFlags{
+		catalogs:  flags.String("catalog", "", "Comma-separated HTTP(S) skill catalog index URLs to mirror alongside the local skills root"),
+		gitRepo:   flags.String("git-repo", "", "Git repository to load skills from, e.g. https://example.com/skills.git"),
+		gitRef:    flags.String("git-ref", "", "Branch or tag of --git-repo to follow (default: remote HEAD)"),
+		gitCommit: flags.String("git-commit", "", "Pin --git-repo to this commit instead of following --git-ref"),
+		gitSubdir: flags.String("git-subdir", "", "Directory within --git-repo containing the skills"),
+		cacheDir:  flags.String("cache-dir", defaultCacheDir(), "Directory caching skills downloaded from remote sources"),
+	}
+}
+
+// build creates the catalog and git sources followed by the embedded skills,
+// and reports how many of the sources are remote.
+func (f sourceFlags) build() ([]skillz.Source, int, error) {
+	sources, err := buildSources(*f.catalogs, *f.cacheDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	if *f.gitRepo != "" {
+		// Processes following different refs or commits get caches of their
+		// own, so none of them moves the checkout another one serves.
+		digest := sha256.Sum256([]byte(*f.gitRepo + "\x00" + *f.gitRef + "\x00" + *f.gitCommit))
+		source, err := skillz.NewGitSource(skillz.GitSourceOptions{
+			Repository: *f.gitRepo,
+			Ref:        *f.gitRef,
+			Commit:     *f.gitCommit,
+			Subdir:     *f.gitSubdir,
+			CacheDir:   filepath.Join(*f.cacheDir, "git", hex.EncodeToString(digest[:8])),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		sources = append(sources, source)
+	}
+	remote := len(sources)
+	embedded, err := embeddedSkills()
+	if err != nil {
+		return nil, 0, err
+	}
+	if embedded != nil {
+		sources = append(sources, embedded)
+	}
+	return sources, remote, nil
+}
+
+// skillsRootFor returns the skills root named by args, falling back to
+// defaultRoot unless it is missing and other sources provide skills.
+func skillsRootFor(args []string, defaultRoot string, sources []skillz.Source) string {
+	if len(args) > 0 && args[0] != "" {
+		return args[0]
+	}
+	if _, err := os.Stat(defaultRoot); err != nil && len(sources) > 0 {
+		return ""
+	}
+	return defaultRoot
+}
+
+// buildSources creates one cache directory per catalog, named after a hash of
+// its URL so catalogs never share archives.
+func buildSources(catalogs string, cacheDir string) ([]skillz.Source, error) {
+	sources := []skillz.Source{}
+	for _, catalogURL := range strings.Split(catalogs, ",") {
+		catalogURL = strings.TrimSpace(catalogURL)
+		if catalogURL == "" {
+			continue
+		}
+		digest := sha256.Sum256([]byte(catalogURL))
+		source, err := skillz.NewHTTPCatalogSource(skillz.HTTPCatalogOptions{
+			URL:      catalogURL,
+			CacheDir: filepath.Join(cacheDir, "catalogs", hex.EncodeToString(digest[:8])),
+		})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func buildAuthenticators(tokens string, apiKeysFile string, jwksFile string, issuer string, audience string) ([]skillz.Authenticator, error) {
+	authenticators := []skillz.Authenticator{}
+	if strings.TrimSpace(tokens) != "" {
+		authenticator, err := skillz.ParseStaticTokens(tokens)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if apiKeysFile != "" {
+		authenticator, err := skillz.LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	if jwksFile != "" {
+		authenticator, err := skillz.LoadJWKS(jwksFile, issuer, audience)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+	return authenticators, nil
+}
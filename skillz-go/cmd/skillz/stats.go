@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/intellectronica/skillz/skillz-go/skillz"
+)
+
+// runStats implements `skillz stats [flags] [skills-root]`, summarizing a
+// usage log recorded with --usage-log. Skills of catalog and git sources are
+// read from the server's cache without syncing them.
+func runStats(args []string, defaultRoot string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	usageLog := flags.String("usage-log", os.Getenv("SKILLZ_USAGE_LOG"), "Usage log written by the server (env SKILLZ_USAGE_LOG)")
+	since := flags.Duration("since", 0, "Only include events newer than this, e.g. 168h")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	sourceOptions := addSourceFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *usageLog == "" {
+		return fmt.Errorf("stats requires --usage-log")
+	}
+
+	sources, _, err := sourceOptions.build()
+	if err != nil {
+		return err
+	}
+	registry := skillz.NewRegistry(skillsRootFor(flags.Args(), defaultRoot, sources))
+	registry.Lazy = true
+	registry.Sources = sources
+	if err := registry.Load(); err != nil {
+		return err
+	}
+
+	events, err := skillz.ReadUsageLog(*usageLog)
+	if err != nil {
+		return err
+	}
+	if *since > 0 {
+		cutoff := time.Now().Add(-*since)
+		recent := events[:0]
+		for _, event := range events {
+			if event.Time.After(cutoff) {
+				recent = append(recent, event)
+			}
+		}
+		events = recent
+	}
+
+	report := skillz.SummarizeUsage(events, registry.Skills())
+	if *asJSON {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(encoded))
+		return nil
+	}
+	report.Render(stdout)
+	return nil
+}
@@ -0,0 +1,376 @@
+package skillz
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits applied to every skill archive so a small file cannot expand into
+// an unbounded number of members or bytes.
+const (
+	maxArchiveMembers           = 10000
+	maxArchiveUncompressedBytes = 1 << 30
+)
+
+// skillArchive is an open skill archive with its skill root located. Member
+// paths are relative to the skill root.
+type skillArchive interface {
+	resources() []ResourceInfo
+	stat(relPath string) (ResourceInfo, error)
+	// open returns a reader for one member that closes the archive with it;
+	// the archive is closed on error too.
+	open(relPath string) (io.ReadCloser, int64, error)
+	Close() error
+}
+
+// openArchive opens the archive candidate name, reusing the member index of
+// an unchanged tarball.
+func (s *fsSource) openArchive(name string) (skillArchive, error) {
+	if !isTarArchiveName(name) {
+		return openZipArchive(s.fsys, name)
+	}
+	stat, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.tarIndexes[name]; ok && cached.size == stat.Size() && cached.modTime.Equal(stat.ModTime()) {
+		return cached, nil
+	}
+	archive, err := indexTarArchive(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	archive.size, archive.modTime = stat.Size(), stat.ModTime()
+	if s.tarIndexes == nil {
+		s.tarIndexes = map[string]*tarArchive{}
+	}
+	s.tarIndexes[name] = archive
+	return archive, nil
+}
+
+// archiveMemberName normalises a member name, reporting false for members
+// that would resolve outside the archive.
+func archiveMemberName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", false
+	}
+	name = path.Clean(name)
+	if name == "." || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// locateArchiveRoot applies the archive layout rule: SKILL.md at the root, or
+// inside the only top-level directory. It returns the member prefix of the
+// skill root.
+func locateArchiveRoot(names []string, exists func(string) bool) (string, bool) {
+	if exists(SkillMarkdown) {
+		return "", true
+	}
+	topDirs := map[string]struct{}{}
+	for _, name := range names {
+		if topDir, _, ok := strings.Cut(name, "/"); ok {
+			topDirs[topDir] = struct{}{}
+		}
+	}
+	if len(topDirs) != 1 {
+		return "", false
+	}
+	for topDir := range topDirs {
+		if exists(path.Join(topDir, SkillMarkdown)) {
+			return topDir + "/", true
+		}
+	}
+	return "", false
+}
+
+// archiveResourceIgnored filters macOS metadata that archivers add.
+func archiveResourceIgnored(relPath string) bool {
+	return relPath == SkillMarkdown || strings.Contains(relPath, "__MACOSX/") || strings.HasSuffix(relPath, ".DS_Store")
+}
+
+// archiveResources lists the members under root as resources.
+func archiveResources[M any](members map[string]M, root string, describe func(M) ResourceInfo) []ResourceInfo {
+	resources := []ResourceInfo{}
+	for name, member := range members {
+		relPath, ok := strings.CutPrefix(name, root)
+		if !ok || archiveResourceIgnored(relPath) {
+			continue
+		}
+		info := describe(member)
+		info.Path = relPath
+		resources = append(resources, info)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Path < resources[j].Path })
+	return resources
+}
+
+// zipArchive is an open zip skill archive.
+type zipArchive struct {
+	file    fs.File
+	members map[string]*zip.File
+	root    string
+}
+
+func openZipArchive(fsys fs.FS, name string) (*zipArchive, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		readerAt = bytes.NewReader(data)
+	}
+	reader, err := zip.NewReader(readerAt, stat.Size())
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %v", errInvalidArchive, err)
+	}
+	if len(reader.File) > maxArchiveMembers {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: more than %d members", errInvalidArchive, maxArchiveMembers)
+	}
+
+	names := make([]string, 0, len(reader.File))
+	members := map[string]*zip.File{}
+	var total uint64
+	for _, member := range reader.File {
+		memberName, ok := archiveMemberName(member.Name)
+		if !ok {
+			continue
+		}
+		if member.FileInfo().IsDir() {
+			names = append(names, memberName+"/")
+			continue
+		}
+		names = append(names, memberName)
+		total += member.UncompressedSize64
+		if total > maxArchiveUncompressedBytes {
+			_ = file.Close()
+			return nil, fmt.Errorf("%w: more than %d uncompressed bytes", errInvalidArchive, maxArchiveUncompressedBytes)
+		}
+		members[memberName] = member
+	}
+	root, ok := locateArchiveRoot(names, func(name string) bool { _, ok := members[name]; return ok })
+	if !ok {
+		_ = file.Close()
+		return nil, errMissingSkillMarkdown
+	}
+	return &zipArchive{file: file, members: members, root: root}, nil
+}
+
+func (a *zipArchive) Close() error {
+	return a.file.Close()
+}
+
+func (a *zipArchive) resources() []ResourceInfo {
+	return archiveResources(a.members, a.root, describeZipMember)
+}
+
+func describeZipMember(member *zip.File) ResourceInfo {
+	return ResourceInfo{Size: int64(member.UncompressedSize64), ModTime: member.Modified}
+}
+
+func (a *zipArchive) stat(relPath string) (ResourceInfo, error) {
+	member, ok := a.members[a.root+relPath]
+	if !ok {
+		return ResourceInfo{}, fs.ErrNotExist
+	}
+	info := describeZipMember(member)
+	info.Path = relPath
+	return info, nil
+}
+
+func (a *zipArchive) open(relPath string) (io.ReadCloser, int64, error) {
+	member, ok := a.members[a.root+relPath]
+	if !ok {
+		_ = a.Close()
+		return nil, 0, fs.ErrNotExist
+	}
+	reader, err := member.Open()
+	if err != nil {
+		_ = a.Close()
+		return nil, 0, err
+	}
+	return archiveMemberReader{ReadCloser: reader, archive: a}, int64(member.UncompressedSize64), nil
+}
+
+// tarArchive indexes a gzipped tarball. Tarballs cannot be read at random, so
+// the index records where each member's data starts in the uncompressed
+// stream; opening a member decompresses up to that offset without parsing
+// the headers in between.
+type tarArchive struct {
+	fsys    fs.FS
+	name    string
+	members map[string]tarMember
+	root    string
+	// size and modTime identify the archive file the index was built from.
+	size    int64
+	modTime time.Time
+}
+
+type tarMember struct {
+	offset  int64
+	size    int64
+	modTime time.Time
+}
+
+// countingReader reports how many bytes have been read through it.
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func indexTarArchive(fsys fs.FS, name string) (*tarArchive, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decompressed, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidArchive, err)
+	}
+	defer decompressed.Close()
+	counter := &countingReader{reader: io.LimitReader(decompressed, maxArchiveUncompressedBytes+1)}
+	reader := tar.NewReader(counter)
+
+	names := []string{}
+	members := map[string]tarMember{}
+	for count := 0; ; count++ {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if counter.read > maxArchiveUncompressedBytes {
+				return nil, fmt.Errorf("%w: more than %d uncompressed bytes", errInvalidArchive, maxArchiveUncompressedBytes)
+			}
+			return nil, fmt.Errorf("%w: %v", errInvalidArchive, err)
+		}
+		if count >= maxArchiveMembers {
+			return nil, fmt.Errorf("%w: more than %d members", errInvalidArchive, maxArchiveMembers)
+		}
+		memberName, ok := archiveMemberName(header.Name)
+		if !ok {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			names = append(names, memberName+"/")
+		case tar.TypeReg:
+			// Sparse files record holes in PAX records; their stored bytes
+			// are not the file contents, so they are not served.
+			if tarHeaderSparse(header) {
+				continue
+			}
+			names = append(names, memberName)
+			members[memberName] = tarMember{offset: counter.read, size: header.Size, modTime: header.ModTime}
+		}
+	}
+	root, ok := locateArchiveRoot(names, func(name string) bool { _, ok := members[name]; return ok })
+	if !ok {
+		return nil, errMissingSkillMarkdown
+	}
+	return &tarArchive{fsys: fsys, name: name, members: members, root: root}, nil
+}
+
+func tarHeaderSparse(header *tar.Header) bool {
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Close is a no-op: the index holds no open file and is shared between reads.
+func (a *tarArchive) Close() error {
+	return nil
+}
+
+func (a *tarArchive) resources() []ResourceInfo {
+	return archiveResources(a.members, a.root, describeTarMember)
+}
+
+func describeTarMember(member tarMember) ResourceInfo {
+	return ResourceInfo{Size: member.size, ModTime: member.modTime}
+}
+
+func (a *tarArchive) stat(relPath string) (ResourceInfo, error) {
+	member, ok := a.members[a.root+relPath]
+	if !ok {
+		return ResourceInfo{}, fs.ErrNotExist
+	}
+	info := describeTarMember(member)
+	info.Path = relPath
+	return info, nil
+}
+
+func (a *tarArchive) open(relPath string) (io.ReadCloser, int64, error) {
+	member, ok := a.members[a.root+relPath]
+	if !ok {
+		return nil, 0, fs.ErrNotExist
+	}
+	file, err := a.fsys.Open(a.name)
+	if err != nil {
+		return nil, 0, err
+	}
+	decompressed, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("%w: %v", errInvalidArchive, err)
+	}
+	if _, err := io.CopyN(io.Discard, decompressed, member.offset); err != nil {
+		_ = decompressed.Close()
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("%w: %v", errInvalidArchive, err)
+	}
+	reader := struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(decompressed, member.size), decompressed}
+	return archiveMemberReader{ReadCloser: reader, archive: file}, member.size, nil
+}
+
+type archiveMemberReader struct {
+	io.ReadCloser
+	archive io.Closer
+}
+
+func (r archiveMemberReader) Close() error {
+	err := r.ReadCloser.Close()
+	if closeErr := r.archive.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
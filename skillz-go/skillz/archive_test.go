@@ -0,0 +1,162 @@
+package skillz
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	content  string
+	typeflag byte
+}
+
+func createTarSkill(t *testing.T, tarPath string, entries ...tarEntry) {
+	t.Helper()
+	file, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatalf("create tarball: %v", err)
+	}
+	defer file.Close()
+	compressed := gzip.NewWriter(file)
+	writer := tar.NewWriter(compressed)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content)), Typeflag: entry.typeflag}
+		if entry.typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+			header.Linkname = entry.content
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("write header %s: %v", entry.name, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := writer.Write([]byte(entry.content)); err != nil {
+				t.Fatalf("write %s: %v", entry.name, err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := compressed.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+}
+
+func TestRegistryDiscoversTarSkill(t *testing.T) {
+	temp := t.TempDir()
+	createTarSkill(t, filepath.Join(temp, "flat.tar.gz"),
+		tarEntry{name: SkillMarkdown, content: skillMarkdown("Flat")},
+		tarEntry{name: "text/hello.txt", content: "hello"},
+	)
+	createTarSkill(t, filepath.Join(temp, "nested.tgz"),
+		tarEntry{name: "./nested/", typeflag: tar.TypeDir},
+		tarEntry{name: "./nested/SKILL.md", content: skillMarkdown("Nested")},
+		tarEntry{name: "./nested/docs/guide.md", content: "guide"},
+		tarEntry{name: "./nested/.DS_Store", content: "junk"},
+		tarEntry{name: "./nested/link", content: "/etc/passwd", typeflag: tar.TypeSymlink},
+	)
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	flat, err := registry.Get("flat")
+	if err != nil {
+		t.Fatalf("get flat: %v", err)
+	}
+	if !flat.IsZip() || !flat.HasResource("text/hello.txt") || flat.Instructions != "Body\n" {
+		t.Fatalf("unexpected flat skill: %+v", flat)
+	}
+	nested, err := registry.Get("nested")
+	if err != nil {
+		t.Fatalf("get nested: %v", err)
+	}
+	if len(nested.Resources) != 1 || !nested.HasResource("docs/guide.md") {
+		t.Fatalf("unexpected nested resources: %v", nested.Resources)
+	}
+	data, err := nested.OpenBytes("docs/guide.md")
+	if err != nil || string(data) != "guide" {
+		t.Fatalf("unexpected resource: %q, %v", data, err)
+	}
+}
+
+func TestLazyRegistryResolvesTarSkill(t *testing.T) {
+	temp := t.TempDir()
+	createTarSkill(t, filepath.Join(temp, "my-skill.tar.gz"),
+		tarEntry{name: SkillMarkdown, content: skillMarkdown("MySkill")},
+		tarEntry{name: "text/hello.txt", content: "hello"},
+	)
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	skill, err := registry.Resolve("myskill")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if skill.Instructions != "Body\n" {
+		t.Fatalf("unexpected instructions: %q", skill.Instructions)
+	}
+	data, err := skill.OpenBytes("text/hello.txt")
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected resource: %q, %v", data, err)
+	}
+}
+
+func TestFetchRangeFromTarMember(t *testing.T) {
+	temp := t.TempDir()
+	createTarSkill(t, filepath.Join(temp, "my-skill.tgz"),
+		tarEntry{name: SkillMarkdown, content: skillMarkdown("MySkill")},
+		tarEntry{name: "text/first.txt", content: "first member"},
+		tarEntry{name: "text/hello.txt", content: "hello"},
+	)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/myskill/text/hello.txt", FetchOptions{Offset: 1, Length: 3})
+	if result["content"] != "ell" || result["next_offset"] != int64(4) {
+		t.Fatalf("unexpected tar range: %q %v", result["content"], result["next_offset"])
+	}
+}
+
+func TestRegistrySkipsUnsafeTarballs(t *testing.T) {
+	temp := t.TempDir()
+	createTarSkill(t, filepath.Join(temp, "two-roots.tar.gz"),
+		tarEntry{name: "one/SKILL.md", content: skillMarkdown("One")},
+		tarEntry{name: "two/readme.txt", content: "two"},
+	)
+	createTarSkill(t, filepath.Join(temp, "escape.tar.gz"),
+		tarEntry{name: "../SKILL.md", content: skillMarkdown("Escape")},
+	)
+	if err := os.WriteFile(filepath.Join(temp, "corrupt.tgz"), []byte("not gzip"), 0o644); err != nil {
+		t.Fatalf("write corrupt: %v", err)
+	}
+	entries := make([]tarEntry, 0, maxArchiveMembers+1)
+	entries = append(entries, tarEntry{name: SkillMarkdown, content: skillMarkdown("Huge")})
+	for index := 0; index < maxArchiveMembers; index++ {
+		entries = append(entries, tarEntry{name: fmt.Sprintf("dir-%d/", index), typeflag: tar.TypeDir})
+	}
+	createTarSkill(t, filepath.Join(temp, "huge.tar.gz"), entries...)
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if skills := registry.Skills(); len(skills) != 0 {
+		t.Fatalf("expected no skills, got %+v", skills)
+	}
+}
@@ -0,0 +1,348 @@
+package skillz
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditGenesisHash is the prev_hash of the first record in an audit log.
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// AuditRecord describes content disclosed to a client. Records are chained:
+// each carries the hash of its predecessor, so editing, reordering or deleting
+// a record breaks every hash after it.
+type AuditRecord struct {
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Transport  string    `json:"transport,omitempty"`
+	Session    string    `json:"session,omitempty"`
+	Subject    string    `json:"subject"`
+	AuthMethod string    `json:"auth_method,omitempty"`
+	Credential string    `json:"credential,omitempty"`
+	URI        string    `json:"uri,omitempty"`
+	// Skill is the skill whose tool was called or that was searched; empty
+	// for searches of every skill.
+	Skill string `json:"skill,omitempty"`
+	// Files lists the resource URIs a search returned lines from.
+	Files    []string `json:"files,omitempty"`
+	Outcome  string   `json:"outcome"`
+	SHA256   string   `json:"sha256,omitempty"`
+	Bytes    int      `json:"bytes"`
+	PrevHash string   `json:"prev_hash"`
+}
+
+// auditLine is the on-disk form of a record. Hash covers the exact bytes of
+// Record, so verification does not depend on re-encoding.
+type auditLine struct {
+	Record json.RawMessage `json:"record"`
+	Hash   string          `json:"hash"`
+}
+
+// AuditLog appends hash-chained AuditRecords as JSON lines. Several processes
+// may append to the same file: each append takes an exclusive file lock and
+// continues the chain from the records other processes appended meanwhile.
+type AuditLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	path     string
+	offset   int64
+	seq      int64
+	lastHash string
+	now      func() time.Time
+}
+
+// OpenAuditLog opens path for appending, continuing the chain from its last
+// record. A log whose tail cannot be parsed is refused rather than forked.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	audit := &AuditLog{file: file, path: path, lastHash: auditGenesisHash, now: time.Now}
+	if err := lockFile(file, true); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	err = audit.readTail()
+	_ = unlockFile(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return audit, nil
+}
+
+// readTail advances the chain head past the records appended since it was
+// last read, by this process or another one.
+func (a *AuditLog) readTail() error {
+	stat, err := a.file.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.Size() == a.offset {
+		return nil
+	}
+	tail := io.NewSectionReader(a.file, a.offset, stat.Size()-a.offset)
+	err = scanAuditLog(tail, a.lastHash, a.seq, func(record AuditRecord, hash string) error {
+		a.seq, a.lastHash = record.Seq, hash
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("audit log %s: %w", a.path, err)
+	}
+	a.offset = stat.Size()
+	return nil
+}
+
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// Record appends record, filling in its sequence number, time and chain hash.
+func (a *AuditLog) Record(record AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := lockFile(a.file, true); err != nil {
+		return err
+	}
+	defer unlockFile(a.file)
+	if err := a.readTail(); err != nil {
+		return err
+	}
+	record.Seq = a.seq + 1
+	record.PrevHash = a.lastHash
+	if record.Time.IsZero() {
+		record.Time = a.now().UTC()
+	}
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	hash := auditHash(encoded)
+	line, err := json.Marshal(auditLine{Record: encoded, Hash: hash})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := a.file.Write(line); err != nil {
+		return err
+	}
+	a.seq, a.lastHash = record.Seq, hash
+	a.offset += int64(len(line))
+	return nil
+}
+
+func (a *AuditLog) recordDisclosure(ctx context.Context, method string, uri string, outcome string, content []byte) error {
+	return a.recordContent(ctx, AuditRecord{Method: method, URI: uri, Outcome: outcome}, content)
+}
+
+func (a *AuditLog) recordContent(ctx context.Context, record AuditRecord, content []byte) error {
+	identity := callerIdentity(ctx)
+	record.Transport = transportFromContext(ctx)
+	record.Subject = identity.Subject
+	record.AuthMethod = identity.Method
+	record.Credential = identity.Credential
+	record.Bytes = len(content)
+	if record.Transport == "" {
+		record.Transport = "local"
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		record.Session = session.SessionID()
+	}
+	if record.Outcome == "ok" {
+		digest := sha256.Sum256(content)
+		record.SHA256 = hex.EncodeToString(digest[:])
+	}
+	return a.Record(record)
+}
+
+// RecordFetch audits a FetchResourceJSON result. It is used by the
+// fetch_resource tool and by the --fetch-resource command line mode.
+func (a *AuditLog) RecordFetch(ctx context.Context, uri string, result map[string]any) error {
+	if code, ok := result["error_code"].(string); ok {
+		return a.recordDisclosure(ctx, "fetch_resource", uri, code, nil)
+	}
+	content, _ := result["content"].(string)
+	disclosed := []byte(content)
+	if result["encoding"] == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return err
+		}
+		disclosed = decoded
+	}
+	return a.recordDisclosure(ctx, "fetch_resource", uri, "ok", disclosed)
+}
+
+func (a *AuditLog) recordSearch(ctx context.Context, skill string, result *mcp.CallToolResult, err error) error {
+	record := AuditRecord{Method: "search_resources", Skill: skill, Outcome: "error"}
+	if err != nil {
+		return a.recordContent(ctx, record, nil)
+	}
+	search, _ := result.StructuredContent.(SearchResult)
+	seen := map[string]bool{}
+	for _, match := range search.Matches {
+		if !seen[match.URI] {
+			seen[match.URI] = true
+			record.Files = append(record.Files, match.URI)
+		}
+	}
+	content, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return err
+	}
+	record.Outcome = "ok"
+	return a.recordContent(ctx, record, content)
+}
+
+func (a *AuditLog) recordSkillCall(ctx context.Context, skill string, result *mcp.CallToolResult, err error) error {
+	record := AuditRecord{Method: "tools/call", Skill: skill, Outcome: "error"}
+	if err != nil || result.IsError {
+		return a.recordContent(ctx, record, nil)
+	}
+	content, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return err
+	}
+	record.Outcome = "ok"
+	return a.recordContent(ctx, record, content)
+}
+
+func (a *AuditLog) serverOptions(registry *Registry, options ServerOptions) []server.ServerOption {
+	toolMiddleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			var auditErr error
+			switch call := options.classifyToolCall(registry, request); call.kind {
+			case fetchResourceCall:
+				if err != nil {
+					return result, err
+				}
+				payload, _ := result.StructuredContent.(map[string]any)
+				auditErr = a.RecordFetch(ctx, call.uri, payload)
+			case searchResourcesCall:
+				auditErr = a.recordSearch(ctx, call.skill, result, err)
+			case skillToolCall:
+				auditErr = a.recordSkillCall(ctx, call.skill, result, err)
+			}
+			// Nothing is disclosed unless it was recorded.
+			if auditErr != nil {
+				return nil, fmt.Errorf("audit: %w", auditErr)
+			}
+			return result, err
+		}
+	}
+	resourceMiddleware := func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			contents, err := next(ctx, request)
+			outcome := "ok"
+			if err != nil {
+				outcome = "error"
+			}
+			if auditErr := a.recordDisclosure(ctx, "resources/read", request.Params.URI, outcome, disclosedContent(contents)); auditErr != nil {
+				return nil, fmt.Errorf("audit: %w", auditErr)
+			}
+			return contents, err
+		}
+	}
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(toolMiddleware),
+		server.WithResourceHandlerMiddleware(resourceMiddleware),
+	}
+}
+
+func disclosedContent(contents []mcp.ResourceContents) []byte {
+	var disclosed []byte
+	for _, content := range contents {
+		switch value := content.(type) {
+		case mcp.TextResourceContents:
+			disclosed = append(disclosed, value.Text...)
+		case mcp.BlobResourceContents:
+			decoded, err := base64.StdEncoding.DecodeString(value.Blob)
+			if err == nil {
+				disclosed = append(disclosed, decoded...)
+			}
+		}
+	}
+	return disclosed
+}
+
+func auditHash(record []byte) string {
+	digest := sha256.Sum256(record)
+	return hex.EncodeToString(digest[:])
+}
+
+// AuditVerification summarizes a verified audit log.
+type AuditVerification struct {
+	Records  int64
+	LastHash string
+}
+
+// VerifyAuditLog checks every record's hash and its link to the previous
+// record. The error names the first line that fails. Truncation of trailing
+// records can only be detected by comparing LastHash with a copy kept
+// elsewhere.
+func VerifyAuditLog(path string) (AuditVerification, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AuditVerification{}, err
+	}
+	defer file.Close()
+
+	verification := AuditVerification{LastHash: auditGenesisHash}
+	err = scanAuditLog(file, auditGenesisHash, 0, func(record AuditRecord, hash string) error {
+		verification.Records, verification.LastHash = record.Seq, hash
+		return nil
+	})
+	return verification, err
+}
+
+func scanAuditLog(reader io.Reader, prevHash string, seq int64, visit func(AuditRecord, string) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line auditLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return fmt.Errorf("line %d: malformed entry: %v", lineNumber, err)
+		}
+		if auditHash(line.Record) != line.Hash {
+			return fmt.Errorf("line %d: record hash mismatch; the record was modified", lineNumber)
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(line.Record, &record); err != nil {
+			return fmt.Errorf("line %d: malformed record: %v", lineNumber, err)
+		}
+		if record.PrevHash != prevHash {
+			return fmt.Errorf("line %d: chain broken; a preceding record was removed or altered", lineNumber)
+		}
+		if record.Seq != seq+1 {
+			return fmt.Errorf("line %d: expected sequence %d, found %d", lineNumber, seq+1, record.Seq)
+		}
+		if err := visit(record, line.Hash); err != nil {
+			return err
+		}
+		prevHash, seq = line.Hash, record.Seq
+	}
+	return scanner.Err()
+}
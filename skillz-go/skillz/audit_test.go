@@ -0,0 +1,155 @@
+package skillz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeAuditedRequests(t *testing.T, logPath string) {
+	t.Helper()
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	auditLog, err := OpenAuditLog(logPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer auditLog.Close()
+	mcpServer := BuildMCPServer(registry, ServerOptions{Audit: auditLog})
+
+	ctx := withTransport(withIdentity(context.Background(), Identity{Subject: "alice", Method: "token", Credential: "abc123"}), "http")
+	callServerWithContext(t, ctx, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/script.py"},
+	})
+	callServerWithContext(t, ctx, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/data.bin"})
+	callServerWithContext(t, ctx, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/missing.txt"},
+	})
+	callServerWithContext(t, ctx, mcpServer, "tools/call", map[string]any{
+		"name":      "testskill",
+		"arguments": map[string]any{"task": "audit me"},
+	})
+	callServerWithContext(t, ctx, mcpServer, "tools/call", map[string]any{
+		"name":      "search_resources",
+		"arguments": map[string]any{"skill": "testskill", "pattern": "hello"},
+	})
+}
+
+func TestAuditLogRecordsDisclosuresAndVerifies(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeAuditedRequests(t, logPath)
+
+	var records []AuditRecord
+	file, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = scanAuditLog(file, auditGenesisHash, 0, func(record AuditRecord, _ string) error {
+		records = append(records, record)
+		return nil
+	})
+	_ = file.Close()
+	if err != nil || len(records) != 5 {
+		t.Fatalf("expected 5 valid records, got %d: %v", len(records), err)
+	}
+
+	scriptDigest := sha256.Sum256([]byte("print('hello')"))
+	fetch := records[0]
+	if fetch.Method != "fetch_resource" || fetch.Subject != "alice" || fetch.Credential != "abc123" || fetch.Transport != "http" ||
+		fetch.Bytes != 14 || fetch.SHA256 != hex.EncodeToString(scriptDigest[:]) || fetch.PrevHash != auditGenesisHash {
+		t.Fatalf("unexpected fetch record: %+v", fetch)
+	}
+	binaryDigest := sha256.Sum256([]byte{0xff, 0xfe, 0x00, 0x01, 0x80, 0x90})
+	if read := records[1]; read.Method != "resources/read" || read.Bytes != 6 || read.SHA256 != hex.EncodeToString(binaryDigest[:]) {
+		t.Fatalf("unexpected read record: %+v", read)
+	}
+	if missing := records[2]; missing.Outcome != "resource_not_found" || missing.SHA256 != "" {
+		t.Fatalf("unexpected error record: %+v", missing)
+	}
+	if call := records[3]; call.Method != "tools/call" || call.Skill != "testskill" || call.Outcome != "ok" || call.Bytes == 0 || call.SHA256 == "" {
+		t.Fatalf("unexpected skill call record: %+v", call)
+	}
+	search := records[4]
+	if search.Method != "search_resources" || search.Skill != "testskill" || search.Bytes == 0 ||
+		len(search.Files) != 1 || search.Files[0] != "resource://skillz/testskill/script.py" {
+		t.Fatalf("unexpected search record: %+v", search)
+	}
+
+	verification, err := VerifyAuditLog(logPath)
+	if err != nil || verification.Records != 5 {
+		t.Fatalf("verify: %+v %v", verification, err)
+	}
+
+	// Reopening continues the chain instead of starting a new one.
+	writeAuditedRequests(t, logPath)
+	if verification, err := VerifyAuditLog(logPath); err != nil || verification.Records != 10 {
+		t.Fatalf("verify after reopen: %+v %v", verification, err)
+	}
+}
+
+func TestAuditLogsSharingAFileKeepOneChain(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	first, err := OpenAuditLog(logPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer first.Close()
+	second, err := OpenAuditLog(logPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer second.Close()
+
+	// Both logs were opened on an empty file, as two server processes would.
+	var wg sync.WaitGroup
+	for _, auditLog := range []*AuditLog{first, second, first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if err := auditLog.Record(AuditRecord{Method: "resources/read", Outcome: "ok"}); err != nil {
+					t.Errorf("record: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if verification, err := VerifyAuditLog(logPath); err != nil || verification.Records != 40 {
+		t.Fatalf("verify: %+v %v", verification, err)
+	}
+}
+
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeAuditedRequests(t, logPath)
+	original, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(original), "\n")
+
+	edited := strings.Replace(string(original), `"subject":"alice"`, `"subject":"mallory"`, 1)
+	deleted := lines[0] + lines[2]
+	for name, content := range map[string]string{"edited": edited, "deleted": deleted} {
+		if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyAuditLog(logPath); err == nil {
+			t.Fatalf("%s log passed verification", name)
+		}
+		if _, err := OpenAuditLog(logPath); err == nil {
+			t.Fatalf("%s log was reopened for appending", name)
+		}
+	}
+}
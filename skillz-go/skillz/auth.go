@@ -0,0 +1,399 @@
+package skillz
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// errNoCredentials is returned by an Authenticator when the request carries no
+// credentials it understands, so the next authenticator can be tried.
+var errNoCredentials = errors.New("no credentials")
+
+type Identity struct {
+	Subject string
+	Groups  []string
+	// Method names the authenticator that produced the identity: token,
+	// api_key or jwt.
+	Method string
+	// Credential is a non-secret fingerprint of the credential presented, so
+	// audit records can tell apart several tokens issued to one subject.
+	Credential string
+}
+
+// credentialFingerprint abbreviates the SHA-256 of a secret.
+func credentialFingerprint(secret []byte) string {
+	digest := sha256.Sum256(secret)
+	return hex.EncodeToString(digest[:8])
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+type identityContextKey struct{}
+
+func withIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext returns the caller authenticated by the HTTP transport.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityContextKey{}).(Identity)
+	return identity, ok
+}
+
+// requireAuthentication rejects requests that none of the authenticators
+// accept and stores the resulting identity in the request context.
+func requireAuthentication(next http.Handler, authenticators []Authenticator) http.Handler {
+	if len(authenticators) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, authenticator := range authenticators {
+			identity, err := authenticator.Authenticate(r)
+			if errors.Is(err, errNoCredentials) {
+				continue
+			}
+			if err != nil {
+				break
+			}
+			next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), identity)))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="skillz"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+type StaticTokenAuthenticator struct {
+	tokens map[string]Identity
+}
+
+// ParseStaticTokens parses a comma-separated list of "identity=token" pairs.
+// A bare token is given the identity "token-N".
+func ParseStaticTokens(spec string) (*StaticTokenAuthenticator, error) {
+	authenticator := &StaticTokenAuthenticator{tokens: map[string]Identity{}}
+	for index, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		subject := fmt.Sprintf("token-%d", index+1)
+		token := entry
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			subject, token = strings.TrimSpace(name), strings.TrimSpace(value)
+		}
+		if subject == "" || token == "" {
+			return nil, fmt.Errorf("invalid auth token entry %q", entry)
+		}
+		authenticator.tokens[token] = Identity{Subject: subject, Method: "token", Credential: credentialFingerprint([]byte(token))}
+	}
+	return authenticator, nil
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return Identity{}, errNoCredentials
+	}
+	var matched Identity
+	found := false
+	for candidate, identity := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			matched, found = identity, true
+		}
+	}
+	if !found {
+		return Identity{}, errNoCredentials
+	}
+	return matched, nil
+}
+
+type APIKeyAuthenticator struct {
+	keys map[string]Identity
+}
+
+// LoadAPIKeys reads a keys file with one "identity sha256-hex [group,...]"
+// entry per line. Keys are presented in the X-API-Key header or as a bearer
+// token and compared by their SHA-256 digest.
+func LoadAPIKeys(path string) (*APIKeyAuthenticator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	authenticator := &APIKeyAuthenticator{keys: map[string]Identity{}}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected 'identity sha256-hex [groups]'", path, lineNumber)
+		}
+		digest := strings.ToLower(strings.TrimPrefix(fields[1], "sha256:"))
+		if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%s:%d: invalid SHA-256 digest", path, lineNumber)
+		}
+		identity := Identity{Subject: fields[0], Method: "api_key", Credential: digest[:16]}
+		if len(fields) == 3 {
+			identity.Groups = strings.Split(fields[2], ",")
+		}
+		authenticator.keys[digest] = identity
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return authenticator, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = bearerToken(r)
+	}
+	if key == "" {
+		return Identity{}, errNoCredentials
+	}
+	digest := sha256.Sum256([]byte(key))
+	identity, ok := a.keys[hex.EncodeToString(digest[:])]
+	if !ok {
+		return Identity{}, errNoCredentials
+	}
+	return identity, nil
+}
+
+// jwtLeeway tolerates clock skew when checking exp and nbf.
+const jwtLeeway = time.Minute
+
+type JWTAuthenticator struct {
+	Issuer   string
+	Audience string
+	keys     map[string]crypto.PublicKey
+	now      func() time.Time
+}
+
+// LoadJWKS reads RSA and EC public keys from a local JWKS file and returns an
+// authenticator for bearer JWTs signed with RS256/384/512 or ES256/384/512.
+func LoadJWKS(path string, issuer string, audience string) (*JWTAuthenticator, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", path, err)
+	}
+
+	authenticator := &JWTAuthenticator{Issuer: issuer, Audience: audience, keys: map[string]crypto.PublicKey{}, now: time.Now}
+	for _, key := range document.Keys {
+		switch key.Kty {
+		case "RSA":
+			n, errN := decodeBigInt(key.N)
+			e, errE := decodeBigInt(key.E)
+			if errN != nil || errE != nil || !e.IsInt64() {
+				return nil, fmt.Errorf("JWKS %s: invalid RSA key %q", path, key.Kid)
+			}
+			authenticator.keys[key.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			curve := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}[key.Crv]
+			x, errX := decodeBigInt(key.X)
+			y, errY := decodeBigInt(key.Y)
+			if curve == nil || errX != nil || errY != nil {
+				return nil, fmt.Errorf("JWKS %s: invalid EC key %q", path, key.Kid)
+			}
+			authenticator.keys[key.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	if len(authenticator.keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s contains no usable keys", path)
+	}
+	return authenticator, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	token := bearerToken(r)
+	if strings.Count(token, ".") != 2 {
+		return Identity{}, errNoCredentials
+	}
+	claims, err := a.verify(token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return Identity{}, errors.New("jwt: missing sub claim")
+	}
+	identity := Identity{Subject: subject, Method: "jwt", Credential: credentialFingerprint([]byte(token))}
+	if id, ok := claims["jti"].(string); ok && id != "" {
+		identity.Credential = "jti:" + id
+	}
+	switch groups := claims["groups"].(type) {
+	case []any:
+		for _, group := range groups {
+			if text, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, text)
+			}
+		}
+	case string:
+		identity.Groups = strings.Fields(groups)
+	}
+	if scope, ok := claims["scope"].(string); ok && identity.Groups == nil {
+		identity.Groups = strings.Fields(scope)
+	}
+	return identity, nil
+}
+
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("jwt: invalid header: %w", err)
+	}
+	claims := map[string]any{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("jwt: invalid claims: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("jwt: invalid signature encoding: %w", err)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok && header.Kid == "" && len(a.keys) == 1 {
+		for _, only := range a.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("jwt: unknown key id %q", header.Kid)
+	}
+	if err := verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	if exp, ok := claims["exp"].(float64); !ok || now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return nil, errors.New("jwt: token expired or missing exp")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("jwt: token not yet valid")
+	}
+	if a.Issuer != "" && claims["iss"] != a.Issuer {
+		return nil, errors.New("jwt: unexpected issuer")
+	}
+	if a.Audience != "" && !jwtAudienceContains(claims["aud"], a.Audience) {
+		return nil, errors.New("jwt: unexpected audience")
+	}
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, target any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, target)
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	hashes := map[string]crypto.Hash{
+		"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+		"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	}
+	hash, ok := hashes[alg]
+	if !ok {
+		return fmt.Errorf("jwt: unsupported algorithm %q", alg)
+	}
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return errors.New("jwt: algorithm does not match key type")
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+			return errors.New("jwt: invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return errors.New("jwt: invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return errors.New("jwt: invalid signature")
+		}
+	default:
+		return errors.New("jwt: unsupported key type")
+	}
+	return nil
+}
+
+func jwtAudienceContains(claim any, audience string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == audience
+	case []any:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package skillz
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func authenticatedSubject(t *testing.T, authenticators []Authenticator, header string, value string) (int, string) {
+	t.Helper()
+	handler := requireAuthentication(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := IdentityFromContext(r.Context())
+		_, _ = w.Write([]byte(identity.Subject))
+	}), authenticators)
+
+	request := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if header != "" {
+		request.Header.Set(header, value)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestStaticTokenAuthentication(t *testing.T) {
+	tokens, err := ParseStaticTokens("alice=secret-a, secret-b")
+	if err != nil {
+		t.Fatalf("parse tokens: %v", err)
+	}
+	authenticators := []Authenticator{tokens}
+
+	if code, subject := authenticatedSubject(t, authenticators, "Authorization", "Bearer secret-a"); code != http.StatusOK || subject != "alice" {
+		t.Fatalf("expected alice, got %d %q", code, subject)
+	}
+	if code, subject := authenticatedSubject(t, authenticators, "Authorization", "bearer secret-b"); code != http.StatusOK || subject != "token-2" {
+		t.Fatalf("expected token-2, got %d %q", code, subject)
+	}
+	if code, _ := authenticatedSubject(t, authenticators, "Authorization", "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", code)
+	}
+	if code, _ := authenticatedSubject(t, authenticators, "", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", code)
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	digest := sha256.Sum256([]byte("key-123"))
+	keysFile := filepath.Join(t.TempDir(), "keys")
+	content := "# team keys\nci-bot " + hex.EncodeToString(digest[:]) + " automation,readers\n"
+	if err := os.WriteFile(keysFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+	keys, err := LoadAPIKeys(keysFile)
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+
+	if code, subject := authenticatedSubject(t, []Authenticator{keys}, "X-API-Key", "key-123"); code != http.StatusOK || subject != "ci-bot" {
+		t.Fatalf("expected ci-bot, got %d %q", code, subject)
+	}
+	if code, _ := authenticatedSubject(t, []Authenticator{keys}, "X-API-Key", "key-124"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", code)
+	}
+}
+
+func signJWT(t *testing.T, alg string, kid string, claims map[string]any, sign func(digest []byte) []byte) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(digest[:]))
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec: %v", err)
+	}
+	encode := func(value *big.Int) string { return base64.RawURLEncoding.EncodeToString(value.Bytes()) }
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa-1", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+	}})
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	authenticator, err := LoadJWKS(jwksFile, "https://issuer.test", "skillz")
+	if err != nil {
+		t.Fatalf("load jwks: %v", err)
+	}
+	authenticators := []Authenticator{authenticator}
+
+	claims := map[string]any{
+		"sub":    "agent-7",
+		"iss":    "https://issuer.test",
+		"aud":    []string{"skillz"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"platform"},
+	}
+	signRSA := func(digest []byte) []byte {
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+		if err != nil {
+			t.Fatalf("sign rsa: %v", err)
+		}
+		return signature
+	}
+	signEC := func(digest []byte) []byte {
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest)
+		if err != nil {
+			t.Fatalf("sign ec: %v", err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature
+	}
+
+	for _, token := range []string{
+		signJWT(t, "RS256", "rsa-1", claims, signRSA),
+		signJWT(t, "ES256", "ec-1", claims, signEC),
+	} {
+		if code, subject := authenticatedSubject(t, authenticators, "Authorization", "Bearer "+token); code != http.StatusOK || subject != "agent-7" {
+			t.Fatalf("expected agent-7, got %d %q", code, subject)
+		}
+	}
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	if code, _ := authenticatedSubject(t, authenticators, "Authorization", "Bearer "+signJWT(t, "RS256", "rsa-1", claims, signRSA)); code != http.StatusUnauthorized {
+		t.Fatalf("expected expired token to be rejected, got %d", code)
+	}
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["aud"] = "other"
+	if code, _ := authenticatedSubject(t, authenticators, "Authorization", "Bearer "+signJWT(t, "RS256", "rsa-1", claims, signRSA)); code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong audience to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,283 @@
+package skillz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxArchiveBytes bounds a single archive downloaded from a catalog.
+const defaultMaxArchiveBytes = 64 << 20
+
+const catalogStateFile = "catalog-state.json"
+
+// CatalogIndex is the JSON document served by an HTTP skill catalog.
+// Archive URLs may be relative to the index URL.
+type CatalogIndex struct {
+	Skills []CatalogEntry `json:"skills"`
+}
+
+type CatalogEntry struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+type HTTPCatalogOptions struct {
+	// URL of the catalog index JSON.
+	URL string
+	// CacheDir holds downloaded archives and the catalog ETag.
+	CacheDir        string
+	Client          *http.Client
+	MaxArchiveBytes int64
+}
+
+// HTTPCatalogSource downloads the .skill archives listed by an HTTP catalog
+// into a local cache and verifies each against its SHA-256 checksum. Archives
+// are replaced only after a complete, verified sync, so an unreachable or
+// broken catalog leaves the last good cache in place. Archives a sync
+// supersedes are no longer discovered but stay on disk until the registry has
+// reloaded, so reads from the previous snapshot keep working. Several
+// processes may share CacheDir: an archive is pruned only once none of them
+// serves it.
+type HTTPCatalogSource struct {
+	*DirSource
+	options HTTPCatalogOptions
+	cache   *sharedCache
+
+	// mu serializes syncs and pruning; stateMu guards state, which
+	// Candidates reads while a sync may be running.
+	mu      sync.Mutex
+	stateMu sync.RWMutex
+	state   catalogState
+}
+
+// catalogState is persisted next to the cache so conditional requests and the
+// last good archive set survive restarts.
+type catalogState struct {
+	ETag     string   `json:"etag,omitempty"`
+	Archives []string `json:"archives"`
+}
+
+func NewHTTPCatalogSource(options HTTPCatalogOptions) (*HTTPCatalogSource, error) {
+	parsed, err := url.Parse(options.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("catalog URL %q must be an http or https URL", options.URL)
+	}
+	if options.CacheDir == "" {
+		return nil, fmt.Errorf("catalog %s requires a cache directory", options.URL)
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: time.Minute}
+	}
+	if options.MaxArchiveBytes <= 0 {
+		options.MaxArchiveBytes = defaultMaxArchiveBytes
+	}
+	dir, err := NewDirSource("catalog:"+options.URL, filepath.Join(options.CacheDir, "skills"))
+	if err != nil {
+		return nil, err
+	}
+	source := &HTTPCatalogSource{DirSource: dir, options: options, cache: newSharedCache(options.CacheDir)}
+	if err := os.MkdirAll(source.Dir(), 0o755); err != nil {
+		return nil, err
+	}
+	unlock, err := source.cache.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if data, err := os.ReadFile(filepath.Join(options.CacheDir, catalogStateFile)); err == nil {
+		_ = json.Unmarshal(data, &source.state)
+	}
+	if err := source.cache.acquire(source.state.Archives...); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (c *HTTPCatalogSource) Dir() string {
+	return c.Root()
+}
+
+func (c *HTTPCatalogSource) Sync(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unlock, err := c.cache.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.options.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if c.state.ETag != "" && c.cacheComplete() {
+		request.Header.Set("If-None-Match", c.state.ETag)
+	}
+	response, err := c.options.Client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("catalog %s: %w", c.options.URL, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("catalog %s: unexpected status %s", c.options.URL, response.Status)
+	}
+
+	var index CatalogIndex
+	if err := json.NewDecoder(io.LimitReader(response.Body, 16<<20)).Decode(&index); err != nil {
+		return false, fmt.Errorf("catalog %s: invalid index: %w", c.options.URL, err)
+	}
+	base := response.Request.URL
+
+	archives := make([]string, 0, len(index.Skills))
+	seen := map[string]bool{}
+	for _, entry := range index.Skills {
+		archive, err := c.fetchArchive(ctx, base, entry)
+		if err != nil {
+			return false, fmt.Errorf("catalog %s: %w", c.options.URL, err)
+		}
+		if !seen[archive] {
+			seen[archive] = true
+			archives = append(archives, archive)
+		}
+	}
+	sort.Strings(archives)
+	if err := c.cache.acquire(archives...); err != nil {
+		return false, err
+	}
+
+	changed := strings.Join(archives, "\n") != strings.Join(c.state.Archives, "\n")
+	c.stateMu.Lock()
+	c.state = catalogState{ETag: response.Header.Get("ETag"), Archives: archives}
+	c.stateMu.Unlock()
+	encoded, err := json.Marshal(c.state)
+	if err != nil {
+		return changed, err
+	}
+	return changed, writeFileAtomic(filepath.Join(c.options.CacheDir, catalogStateFile), encoded)
+}
+
+// Candidates lists the cached archives of the last good sync, in name order.
+func (c *HTTPCatalogSource) Candidates(ctx context.Context) ([]string, error) {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	candidates := make([]string, 0, len(c.state.Archives))
+	for _, archive := range c.state.Archives {
+		candidate := filepath.Join(c.Dir(), archive)
+		if _, err := os.Stat(candidate); err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+// prune releases the archives the last good sync no longer lists and
+// removes those that no other process serves either. It skips pruning while
+// a sync is running, since that sync's downloads are not listed yet; the next
+// reload prunes them.
+func (c *HTTPCatalogSource) prune() error {
+	if !c.mu.TryLock() {
+		return nil
+	}
+	defer c.mu.Unlock()
+	current := map[string]bool{}
+	for _, archive := range c.state.Archives {
+		current[archive] = true
+	}
+	c.cache.releaseExcept(current)
+	unlock, err := c.cache.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.cache.prune(c.Dir(), current, func(name string) error {
+		return os.RemoveAll(filepath.Join(c.Dir(), name))
+	})
+}
+
+// cacheComplete reports whether every archive of the last sync is still on
+// disk, so a 304 response can be trusted.
+func (c *HTTPCatalogSource) cacheComplete() bool {
+	for _, archive := range c.state.Archives {
+		if _, err := os.Stat(filepath.Join(c.Dir(), archive)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchArchive ensures the archive for entry is cached and returns its file
+// name. Names embed the checksum, so an unchanged entry is never downloaded
+// again and a changed one never overwrites the archive being served.
+func (c *HTTPCatalogSource) fetchArchive(ctx context.Context, base *url.URL, entry CatalogEntry) (string, error) {
+	checksum := strings.ToLower(strings.TrimPrefix(entry.SHA256, "sha256:"))
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("skill %q: invalid sha256 %q", entry.Name, entry.SHA256)
+	}
+	reference, err := url.Parse(entry.URL)
+	if err != nil || entry.URL == "" {
+		return "", fmt.Errorf("skill %q: invalid url %q", entry.Name, entry.URL)
+	}
+	name := slugify(entry.Name)
+	if name == "" {
+		name = "skill"
+	}
+	archive := fmt.Sprintf("%s-%s.skill", name, checksum[:16])
+	target := filepath.Join(c.Dir(), archive)
+	if _, err := os.Stat(target); err == nil {
+		return archive, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, base.ResolveReference(reference).String(), nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := c.options.Client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("skill %q: %w", entry.Name, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("skill %q: unexpected status %s", entry.Name, response.Status)
+	}
+
+	temp, err := os.CreateTemp(c.Dir(), ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+	digest := sha256.New()
+	written, err := io.Copy(io.MultiWriter(temp, digest), io.LimitReader(response.Body, c.options.MaxArchiveBytes+1))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("skill %q: %w", entry.Name, err)
+	}
+	if written > c.options.MaxArchiveBytes {
+		return "", fmt.Errorf("skill %q: archive exceeds %d bytes", entry.Name, c.options.MaxArchiveBytes)
+	}
+	if actual := hex.EncodeToString(digest.Sum(nil)); actual != checksum {
+		return "", fmt.Errorf("skill %q: checksum mismatch: expected %s, got %s", entry.Name, checksum, actual)
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
@@ -0,0 +1,274 @@
+package skillz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testCatalog serves an index of archives built with createZipSkill.
+type testCatalog struct {
+	t           *testing.T
+	mu          sync.Mutex
+	archives    map[string][]byte
+	index       CatalogIndex
+	etag        string
+	notModified int
+}
+
+func newTestCatalog(t *testing.T) (*testCatalog, *httptest.Server) {
+	catalog := &testCatalog{t: t, archives: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		catalog.mu.Lock()
+		defer catalog.mu.Unlock()
+		if r.URL.Path == "/index.json" {
+			if r.Header.Get("If-None-Match") == catalog.etag {
+				catalog.notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", catalog.etag)
+			_ = json.NewEncoder(w).Encode(catalog.index)
+			return
+		}
+		data, ok := catalog.archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return catalog, server
+}
+
+// publish replaces the catalog contents with one archive per skill name.
+func (c *testCatalog) publish(etag string, names ...string) {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index = CatalogIndex{}
+	for _, name := range names {
+		path := filepath.Join(c.t.TempDir(), name+".skill")
+		createZipSkill(c.t, path, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		digest := sha256.Sum256(data)
+		c.archives["/archives/"+name+".skill"] = data
+		c.index.Skills = append(c.index.Skills, CatalogEntry{Name: name, URL: "archives/" + name + ".skill", SHA256: hex.EncodeToString(digest[:])})
+	}
+	c.etag = etag
+}
+
+func TestHTTPCatalogSourceDownloadsAndUsesETag(t *testing.T) {
+	catalog, server := newTestCatalog(t)
+	catalog.publish(`"v1"`, "remote-one")
+	source, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+
+	registry := NewRegistry("")
+	registry.Sources = []Source{source}
+	if changed, err := SyncSources(context.Background(), registry); err != nil || !changed {
+		t.Fatalf("first sync: changed=%v err=%v", changed, err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	skill, err := registry.Get("remote-one")
+	if err != nil {
+		t.Fatalf("remote skill not loaded: %v", err)
+	}
+	if skill.Origin != source.Name() || !skill.IsZip() {
+		t.Fatalf("unexpected skill: %+v", skill)
+	}
+
+	if changed, err := source.Sync(context.Background()); err != nil || changed {
+		t.Fatalf("second sync: changed=%v err=%v", changed, err)
+	}
+	if catalog.notModified != 1 {
+		t.Fatalf("expected a conditional request, got %d", catalog.notModified)
+	}
+}
+
+func TestHTTPCatalogSourceKeepsLastGoodCache(t *testing.T) {
+	catalog, server := newTestCatalog(t)
+	catalog.publish(`"v1"`, "remote-one")
+	cacheDir := t.TempDir()
+	source, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := source.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// A corrupted update is rejected without touching the cache.
+	catalog.publish(`"v2"`, "remote-two")
+	catalog.mu.Lock()
+	catalog.index.Skills[0].SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+	catalog.mu.Unlock()
+	if _, err := source.Sync(context.Background()); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+
+	// An unreachable catalog keeps serving the cache, even after a restart.
+	server.Close()
+	restarted, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	registry := NewRegistry("")
+	registry.Sources = []Source{restarted}
+	if _, err := SyncSources(context.Background(), registry); err == nil {
+		t.Fatal("expected unreachable catalog error")
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	skills := registry.Skills()
+	if len(skills) != 1 || skills[0].Slug != "remote-one" {
+		t.Fatalf("expected cached skill, got %+v", skills)
+	}
+}
+
+func TestHTTPCatalogSourceKeepsSupersededArchivesUntilReload(t *testing.T) {
+	catalog, server := newTestCatalog(t)
+	catalog.publish(`"v1"`, "remote-one")
+	source, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	source.cache.grace = 0
+	registry := NewRegistry("")
+	registry.Lazy = true
+	registry.Sources = []Source{source}
+	if _, err := SyncSources(context.Background(), registry); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// The snapshot being served still reads from the superseded archive,
+	// which the next scan no longer discovers.
+	catalog.publish(`"v2"`, "remote-two")
+	if changed, err := SyncSources(context.Background(), registry); err != nil || !changed {
+		t.Fatalf("sync update: changed=%v err=%v", changed, err)
+	}
+	skill, err := registry.Resolve("remote-one")
+	if err != nil || skill.Instructions != "Zip Body\n" {
+		t.Fatalf("expected the served skill to stay readable, got %q: %v", skill.Instructions, err)
+	}
+	candidates, err := source.Candidates(context.Background())
+	if err != nil || len(candidates) != 1 || filepath.Base(candidates[0])[:len("remote-two")] != "remote-two" {
+		t.Fatalf("expected only the current archive to be discovered, got %v: %v", candidates, err)
+	}
+
+	if err := registry.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	archives, err := filepath.Glob(filepath.Join(source.Dir(), "*.skill"))
+	if err != nil || len(archives) != 1 || filepath.Base(archives[0])[:len("remote-two")] != "remote-two" {
+		t.Fatalf("expected the superseded archive to be pruned after the reload, got %v", archives)
+	}
+}
+
+func TestRefreshSkillsSwapsToolsAfterCatalogUpdate(t *testing.T) {
+	catalog, server := newTestCatalog(t)
+	catalog.publish(`"v1"`, "remote-one")
+	source, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	source.cache.grace = 0
+	local := t.TempDir()
+	writeSkill(t, local, "local-skill")
+	registry := NewRegistry(local)
+	registry.Sources = []Source{source}
+	if _, err := SyncSources(context.Background(), registry); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	options := ServerOptions{}
+	mcpServer := BuildMCPServer(registry, options)
+
+	catalog.publish(`"v2"`, "remote-two")
+	if changed, err := SyncSources(context.Background(), registry); err != nil || !changed {
+		t.Fatalf("sync update: changed=%v err=%v", changed, err)
+	}
+	previous := registry.Skills()
+	if err := registry.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	RefreshSkills(mcpServer, registry, previous, options)
+
+	tools := map[string]bool{}
+	for _, tool := range mcpServer.ListTools() {
+		tools[tool.Tool.Name] = true
+	}
+	if !tools["remote-two"] || tools["remote-one"] || !tools["local-skill"] {
+		t.Fatalf("unexpected tools after refresh: %v", tools)
+	}
+	archives, err := filepath.Glob(filepath.Join(source.Dir(), "*.skill"))
+	if err != nil || len(archives) != 1 || filepath.Base(archives[0])[:len("remote-two")] != "remote-two" {
+		t.Fatalf("expected only the current archive in the cache, got %v", archives)
+	}
+}
+
+func TestHTTPCatalogSourcesSharingACacheKeepEachOthersArchives(t *testing.T) {
+	catalog, server := newTestCatalog(t)
+	catalog.publish(`"v1"`, "remote-one")
+	cacheDir := t.TempDir()
+	older, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := older.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// A second process syncs an update and prunes while the first one still
+	// serves the superseded archive.
+	catalog.publish(`"v2"`, "remote-two")
+	newer, err := NewHTTPCatalogSource(HTTPCatalogOptions{URL: server.URL + "/index.json", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	newer.cache.grace = 0
+	if _, err := newer.Sync(context.Background()); err != nil {
+		t.Fatalf("sync update: %v", err)
+	}
+	if err := newer.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	candidates, err := older.Candidates(context.Background())
+	if err != nil || len(candidates) != 1 {
+		t.Fatalf("expected the first process's archive to survive, got %v: %v", candidates, err)
+	}
+
+	// Once the first process moves on too, the archive goes.
+	older.cache.grace = 0
+	if _, err := older.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := older.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	archives, err := filepath.Glob(filepath.Join(cacheDir, "skills", "*.skill"))
+	if err != nil || len(archives) != 1 || filepath.Base(archives[0])[:len("remote-two")] != "remote-two" {
+		t.Fatalf("expected only the current archive in the cache, got %v", archives)
+	}
+}
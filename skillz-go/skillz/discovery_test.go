@@ -0,0 +1,103 @@
+package skillz
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// generateSkillTree writes count skills under root in nested groups of 100,
+// packaging every tenth skill as a zip archive. Every fiftieth skill reuses
+// the name of the one before it, so first-wins resolution is exercised.
+func generateSkillTree(tb testing.TB, root string, count int) {
+	tb.Helper()
+	for index := 0; index < count; index++ {
+		group := filepath.Join(root, fmt.Sprintf("group-%03d", index/100))
+		if err := os.MkdirAll(group, 0o755); err != nil {
+			tb.Fatalf("mkdir: %v", err)
+		}
+		name := fmt.Sprintf("skill-%05d", index)
+		if index%50 == 49 {
+			name = fmt.Sprintf("skill-%05d", index-1)
+		}
+		options := []skillOption{withName(name), withDescription("Generated skill"), withBody("Body\n"), withFile("docs/readme.txt", "readme")}
+		if index%10 == 0 {
+			createZipSkill(tb, filepath.Join(group, fmt.Sprintf("packed-%05d.zip", index)), name, options...)
+			continue
+		}
+		writeSkill(tb, group, fmt.Sprintf("dir-%05d", index), options...)
+	}
+}
+
+func TestParallelDiscoveryMatchesSerial(t *testing.T) {
+	temp := t.TempDir()
+	generateSkillTree(t, temp, 300)
+
+	load := func(concurrency int) []Skill {
+		registry := NewRegistry(temp)
+		registry.Concurrency = concurrency
+		if err := registry.Load(); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		skills := registry.Skills()
+		for index := range skills {
+			skills[index].MetadataLoadTime = 0
+		}
+		return skills
+	}
+	serial := load(1)
+	if len(serial) != 294 {
+		t.Fatalf("expected 294 unique skills, got %d", len(serial))
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		if parallel := load(16); !reflect.DeepEqual(serial, parallel) {
+			t.Fatalf("parallel discovery differs from serial discovery")
+		}
+	}
+	for _, skill := range serial {
+		if skill.Slug == "skill-00048" && filepath.Base(skill.Directory) != "dir-00048" {
+			t.Fatalf("expected the first skill discovered to win, got %s", skill.Directory)
+		}
+	}
+}
+
+func BenchmarkRegistryLoad(b *testing.B) {
+	temp := b.TempDir()
+	generateSkillTree(b, temp, 5000)
+	indexDir := b.TempDir()
+
+	for _, benchmark := range []struct {
+		name        string
+		lazy        bool
+		concurrency int
+		indexed     bool
+	}{
+		{"serial", false, 1, false},
+		{"parallel", false, 0, false},
+		{"lazy-serial", true, 1, false},
+		{"lazy-parallel", true, 0, false},
+		{"indexed", false, 0, true},
+		{"lazy-indexed", true, 0, true},
+	} {
+		b.Run(benchmark.name, func(b *testing.B) {
+			load := func() {
+				registry := NewRegistry(temp)
+				registry.Lazy = benchmark.lazy
+				registry.Concurrency = benchmark.concurrency
+				if benchmark.indexed {
+					registry.IndexPath = filepath.Join(indexDir, benchmark.name+".json")
+				}
+				if err := registry.Load(); err != nil {
+					b.Fatalf("load: %v", err)
+				}
+			}
+			load()
+			b.ResetTimer()
+			for iteration := 0; iteration < b.N; iteration++ {
+				load()
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+// Package skillz loads Agent Skills (directories or archives holding a
+// SKILL.md and its resources) and publishes them over the Model Context
+// Protocol.
+//
+// A Registry discovers skills in a root directory and in any number of
+// Sources: local directories, archives, an fs.FS such as an embed.FS, HTTP
+// catalogs or git repositories. NewServer builds a standalone MCP server for
+// a registry; hosts that already run a server.MCPServer add skills to it with
+// ServerMiddleware and Mount. Middleware runs custom logic around skill
+// invocations, resource reads and registry loads. FetchResourceJSONWithOptions,
+// SearchResources and ListSkillFiles read skill resources directly, without
+// MCP.
+package skillz
@@ -0,0 +1,71 @@
+package skillz_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing/fstest"
+
+	"github.com/intellectronica/skillz/skillz-go/skillz"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var exampleSkills = fstest.MapFS{
+	"greeting/SKILL.md":         {Data: []byte("---\nname: greeting\ndescription: Greet people politely\n---\nSay hello.\n")},
+	"greeting/phrases.txt":      {Data: []byte("hello\nbonjour\n")},
+	"internal-notes/SKILL.md":   {Data: []byte("---\nname: internal-notes\ndescription: Not for agents\n---\nSecret.\n")},
+	"internal-notes/readme.txt": {Data: []byte("private")},
+}
+
+func exampleRegistry() *skillz.Registry {
+	registry := skillz.NewRegistry("")
+	registry.Sources = []skillz.Source{skillz.NewFSSource("example", exampleSkills)}
+	if err := registry.Load(); err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// Mount skills into a server that also serves the host's own tools.
+func ExampleMount() {
+	registry := exampleRegistry()
+	hooks := &server.Hooks{}
+	options := []skillz.Option{
+		skillz.WithHooks(hooks),
+		skillz.WithFilter(func(skill skillz.Skill) bool { return skill.Slug != "internal-notes" }),
+		skillz.WithExposure(skillz.ExposeSkillTools | skillz.ExposeHelperTools),
+	}
+
+	host := server.NewMCPServer("agent-host", "1.0.0",
+		append(skillz.ServerMiddleware(registry, options...), server.WithToolCapabilities(true))...,
+	)
+	host.AddTool(mcp.NewTool("host_status"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	skillz.Mount(host, registry, options...)
+
+	names := []string{}
+	for name := range host.ListTools() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println(names)
+	// Output: [fetch_resource greeting host_status list_skill_files search_resources]
+}
+
+// Build a standalone server, e.g. to serve with skillz.RunMCPServer.
+func ExampleNewServer() {
+	registry := exampleRegistry()
+	mcpServer := skillz.NewServer(registry, skillz.WithMaxPayloadBytes(64<<10))
+	fmt.Println(mcpServer.GetTool("greeting") != nil)
+	// Output: true
+}
+
+// Read a skill resource without going through MCP.
+func ExampleFetchResourceJSONWithOptions() {
+	registry := exampleRegistry()
+	result := skillz.FetchResourceJSONWithOptions(registry, "resource://skillz/greeting/phrases.txt", skillz.FetchOptions{StartLine: 2, EndLine: 2})
+	fmt.Printf("%q\n", result["content"])
+	// Output: "bonjour\n"
+}
@@ -0,0 +1,128 @@
+package skillz
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FileNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	Type     string      `json:"type"`
+	Size     int64       `json:"size"`
+	MIMEType any         `json:"mime_type,omitempty"`
+	Modified string      `json:"modified,omitempty"`
+	URI      string      `json:"uri,omitempty"`
+	Children []*FileNode `json:"children,omitempty"`
+	// Truncated marks directories whose children lie beyond the requested depth.
+	Truncated bool `json:"truncated,omitempty"`
+
+	modTime time.Time
+}
+
+// ListSkillFiles returns the resource tree of a skill rooted at dir. A depth of
+// zero lists the whole tree; otherwise only depth levels below dir are
+// expanded. Directory sizes and modification times aggregate their files.
+func ListSkillFiles(registry *Registry, slug string, dir string, depth int) (*FileNode, error) {
+	skill, err := registry.Resolve(slug)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := skill.ResourceInfos()
+	if err != nil {
+		return nil, SkillError{Code: "skill_error", Message: fmt.Sprintf("failed to list files of '%s': %v", slug, err)}
+	}
+
+	dir = strings.Trim(normalizeRelPath(dir), "/")
+	if dir == "." {
+		dir = ""
+	}
+	if strings.Contains(dir, "..") {
+		return nil, SkillError{Code: "validation_error", Message: "invalid path: path traversal not allowed"}
+	}
+
+	root := &FileNode{Name: path.Base(dir), Path: dir, Type: "directory"}
+	if dir == "" {
+		root.Name = skill.Slug
+	}
+	found := dir == ""
+	for _, info := range infos {
+		if info.Path == dir {
+			node := newFileNode(skill, info)
+			finishFileNode(node)
+			return node, nil
+		}
+		rel := info.Path
+		if dir != "" {
+			if !strings.HasPrefix(info.Path, dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(info.Path, dir+"/")
+		}
+		found = true
+		insertFileNode(skill, root, strings.Split(rel, "/"), info, depth, 1)
+	}
+	if !found {
+		return nil, SkillError{Code: "skill_error", Message: fmt.Sprintf("path not found: %s", dir)}
+	}
+	finishFileNode(root)
+	return root, nil
+}
+
+func newFileNode(skill Skill, info ResourceInfo) *FileNode {
+	return &FileNode{
+		Name:     path.Base(info.Path),
+		Path:     info.Path,
+		Type:     "file",
+		Size:     info.Size,
+		MIMEType: detectMimeType(info.Path),
+		URI:      BuildResourceURI(skill, info.Path),
+		modTime:  info.ModTime,
+	}
+}
+
+func insertFileNode(skill Skill, parent *FileNode, parts []string, info ResourceInfo, depth int, level int) {
+	parent.Size += info.Size
+	if info.ModTime.After(parent.modTime) {
+		parent.modTime = info.ModTime
+	}
+	if depth > 0 && level > depth {
+		parent.Truncated = true
+		return
+	}
+	if len(parts) == 1 {
+		parent.Children = append(parent.Children, newFileNode(skill, info))
+		return
+	}
+
+	var child *FileNode
+	for _, existing := range parent.Children {
+		if existing.Type == "directory" && existing.Name == parts[0] {
+			child = existing
+			break
+		}
+	}
+	if child == nil {
+		child = &FileNode{Name: parts[0], Path: path.Join(parent.Path, parts[0]), Type: "directory"}
+		parent.Children = append(parent.Children, child)
+	}
+	insertFileNode(skill, child, parts[1:], info, depth, level+1)
+}
+
+func finishFileNode(node *FileNode) {
+	if !node.modTime.IsZero() {
+		node.Modified = node.modTime.UTC().Format(time.RFC3339)
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Type != node.Children[j].Type {
+			return node.Children[i].Type == "directory"
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for _, child := range node.Children {
+		finishFileNode(child)
+	}
+}
@@ -0,0 +1,99 @@
+package skillz
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListSkillFilesBuildsTree(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "tree")
+	files := map[string]string{
+		"README.md":          "readme",
+		"docs/guide.md":      "guide",
+		"docs/deep/note.txt": "note!",
+	}
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tree, err := ListSkillFiles(registry, "tree", "", 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if tree.Size != 16 || len(tree.Children) != 2 {
+		t.Fatalf("unexpected root: size=%d children=%d", tree.Size, len(tree.Children))
+	}
+	docs := tree.Children[0]
+	if docs.Type != "directory" || docs.Name != "docs" || len(docs.Children) != 2 {
+		t.Fatalf("unexpected docs node: %+v", docs)
+	}
+	readme := tree.Children[1]
+	if readme.MIMEType != "text/markdown" || readme.URI != "resource://skillz/tree/README.md" || readme.Modified == "" {
+		t.Fatalf("unexpected file node: %+v", readme)
+	}
+
+	shallow, err := ListSkillFiles(registry, "tree", "docs", 1)
+	if err != nil {
+		t.Fatalf("list docs: %v", err)
+	}
+	deep := shallow.Children[0]
+	if deep.Name != "deep" || !deep.Truncated || len(deep.Children) != 0 || deep.Size != 5 {
+		t.Fatalf("expected truncated deep directory, got %+v", deep)
+	}
+}
+
+func TestListSkillFilesFromZip(t *testing.T) {
+	temp := t.TempDir()
+	zipPath := filepath.Join(temp, "nested.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	writer := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"nested/SKILL.md":        "---\nname: nested\ndescription: Zip tree\n---\nBody\n",
+		"nested/assets/logo.svg": "<svg/>",
+	} {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("create entry: %v", err)
+		}
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	_ = file.Close()
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tree, err := ListSkillFiles(registry, "nested", "assets", 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Size != 6 || tree.Children[0].Path != "assets/logo.svg" {
+		t.Fatalf("unexpected zip tree: %+v", tree.Children)
+	}
+	if _, err := ListSkillFiles(registry, "nested", "missing", 0); err == nil {
+		t.Fatalf("expected missing path error")
+	}
+}
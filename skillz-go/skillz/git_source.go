@@ -0,0 +1,278 @@
+package skillz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type GitSourceOptions struct {
+	// Repository is anything git can clone: a URL, file:// URL or local path.
+	Repository string
+	// Ref is the branch or tag to follow; empty means the remote HEAD.
+	Ref string
+	// Commit pins the source to a specific commit instead of following Ref.
+	Commit string
+	// Subdir is the directory within the repository holding the skills.
+	Subdir string
+	// CacheDir holds the local repository and a checkout per revision.
+	CacheDir string
+	// GitBinary defaults to "git" on PATH.
+	GitBinary string
+}
+
+// GitSource serves skills from a checkout of a git repository. Sync fetches
+// the configured ref and checks the commit it points to out into a worktree
+// of its own, so the checkout being served is never rewritten; worktrees of
+// earlier commits are removed once the registry has reloaded and no other
+// process sharing CacheDir serves them. The last good commit keeps serving
+// when the fetch fails.
+type GitSource struct {
+	*DirSource
+	options GitSourceOptions
+	cache   *sharedCache
+
+	// mu serializes syncs and pruning; stateMu guards revision, which
+	// Candidates reads while a sync may be running.
+	mu       sync.Mutex
+	stateMu  sync.RWMutex
+	revision string
+}
+
+func NewGitSource(options GitSourceOptions) (*GitSource, error) {
+	if strings.TrimSpace(options.Repository) == "" {
+		return nil, fmt.Errorf("git source requires a repository")
+	}
+	if options.CacheDir == "" {
+		return nil, fmt.Errorf("git source %s requires a cache directory", options.Repository)
+	}
+	if options.GitBinary == "" {
+		options.GitBinary = "git"
+	}
+	if options.Ref == "" {
+		options.Ref = "HEAD"
+	}
+	subdir := filepath.Clean(filepath.FromSlash(options.Subdir))
+	if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("git source subdir %q must be relative to the repository", options.Subdir)
+	}
+	options.Subdir = subdir
+	// The source spans every worktree, so candidates of the snapshot being
+	// served stay readable after Sync moves on to a new commit.
+	dir, err := NewDirSource(gitSourceName(options), filepath.Join(options.CacheDir, "worktrees"))
+	if err != nil {
+		return nil, err
+	}
+	source := &GitSource{DirSource: dir, options: options, cache: newSharedCache(options.CacheDir)}
+	if source.cloned() {
+		unlock, err := source.cache.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		// Restore the commit served before a restart without fetching.
+		if revision, err := source.git(context.Background(), "rev-parse", "--verify", "--quiet", "HEAD^{commit}"); err == nil {
+			if source.addWorktree(context.Background(), revision) == nil && source.cache.acquire(revision) == nil {
+				source.revision = revision
+			}
+		}
+	}
+	return source, nil
+}
+
+func gitSourceName(options GitSourceOptions) string {
+	name := "git:" + options.Repository
+	if options.Commit != "" {
+		return name + "@" + options.Commit
+	}
+	if options.Ref != "HEAD" {
+		name += "#" + options.Ref
+	}
+	return name
+}
+
+// Dir is the skills directory in the worktree of the current revision.
+func (g *GitSource) Dir() string {
+	return filepath.Join(g.worktreeDir(g.Revision()), g.options.Subdir)
+}
+
+// Revision is the commit currently checked out, or "" before the first Sync.
+func (g *GitSource) Revision() string {
+	g.stateMu.RLock()
+	defer g.stateMu.RUnlock()
+	return g.revision
+}
+
+// candidateRevision is the commit of the worktree holding candidate.
+func (g *GitSource) candidateRevision(candidate string) string {
+	relative, err := filepath.Rel(g.Root(), candidate)
+	if err != nil {
+		return ""
+	}
+	return strings.Split(filepath.ToSlash(relative), "/")[0]
+}
+
+// Candidates lists the skills in the worktree of the current revision.
+func (g *GitSource) Candidates(ctx context.Context) ([]string, error) {
+	if g.Revision() == "" {
+		return nil, nil
+	}
+	current, err := NewDirSource(g.Name(), g.Dir())
+	if err != nil {
+		return nil, err
+	}
+	return current.Candidates(ctx)
+}
+
+func (g *GitSource) worktreeDir(revision string) string {
+	return filepath.Join(g.Root(), revision)
+}
+
+// checkoutDir holds the repository that fetches land in and that the
+// worktrees belong to.
+func (g *GitSource) checkoutDir() string {
+	return filepath.Join(g.options.CacheDir, "checkout")
+}
+
+func (g *GitSource) Sync(ctx context.Context) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	unlock, err := g.cache.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if !g.cloned() {
+		if err := os.MkdirAll(g.checkoutDir(), 0o755); err != nil {
+			return false, err
+		}
+		if _, err := g.git(ctx, "init", "--quiet"); err != nil {
+			return false, err
+		}
+		if _, err := g.git(ctx, "remote", "add", "origin", g.options.Repository); err != nil {
+			return false, err
+		}
+	}
+
+	target, err := g.resolveTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+	if target == g.revision {
+		return false, nil
+	}
+	if err := g.addWorktree(ctx, target); err != nil {
+		return false, err
+	}
+	if err := g.cache.acquire(target); err != nil {
+		return false, err
+	}
+	// HEAD of the repository records the revision served across restarts.
+	if _, err := g.git(ctx, "update-ref", "--no-deref", "HEAD", target); err != nil {
+		return false, err
+	}
+	g.stateMu.Lock()
+	g.revision = target
+	g.stateMu.Unlock()
+	return true, nil
+}
+
+// addWorktree checks revision out into a worktree of its own, unless one
+// already exists.
+func (g *GitSource) addWorktree(ctx context.Context, revision string) error {
+	dir := g.worktreeDir(revision)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return nil
+	}
+	// Forget a worktree left half-written by an interrupted checkout.
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "worktree", "prune"); err != nil {
+		return err
+	}
+	_, err := g.git(ctx, "worktree", "add", "--quiet", "--force", "--detach", dir, revision)
+	return err
+}
+
+// prune releases the worktrees of revisions other than the current one and
+// removes those that no other process serves either. It skips pruning while
+// a sync is running; the next reload prunes instead.
+func (g *GitSource) prune() error {
+	if !g.mu.TryLock() {
+		return nil
+	}
+	defer g.mu.Unlock()
+	current := map[string]bool{g.revision: true}
+	g.cache.releaseExcept(current)
+	unlock, err := g.cache.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	removed := false
+	err = g.cache.prune(g.Root(), current, func(revision string) error {
+		removed = true
+		return os.RemoveAll(g.worktreeDir(revision))
+	})
+	if removed {
+		_, pruneErr := g.git(context.Background(), "worktree", "prune")
+		err = errors.Join(err, pruneErr)
+	}
+	return err
+}
+
+func (g *GitSource) cloned() bool {
+	_, err := os.Stat(filepath.Join(g.checkoutDir(), ".git"))
+	return err == nil
+}
+
+// resolveTarget fetches what is needed and returns the commit to check out.
+func (g *GitSource) resolveTarget(ctx context.Context) (string, error) {
+	if g.options.Commit != "" {
+		if commit, err := g.git(ctx, "rev-parse", "--verify", "--quiet", g.options.Commit+"^{commit}"); err == nil {
+			return commit, nil
+		}
+		refspecs := []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}
+		if g.options.Ref != "HEAD" {
+			refspecs = []string{g.options.Ref}
+		}
+		if _, err := g.git(ctx, append([]string{"fetch", "--quiet", "origin"}, refspecs...)...); err != nil {
+			return "", err
+		}
+		commit, err := g.git(ctx, "rev-parse", "--verify", "--quiet", g.options.Commit+"^{commit}")
+		if err != nil {
+			return "", fmt.Errorf("git source %s: commit %s not found", g.options.Repository, g.options.Commit)
+		}
+		return commit, nil
+	}
+
+	if _, err := g.git(ctx, "fetch", "--quiet", "--force", "origin", g.options.Ref); err != nil {
+		return "", err
+	}
+	return g.git(ctx, "rev-parse", "--verify", "FETCH_HEAD^{commit}")
+}
+
+// git runs a git command in the checkout and returns its trimmed stdout.
+func (g *GitSource) git(ctx context.Context, args ...string) (string, error) {
+	command := exec.CommandContext(ctx, g.options.GitBinary, args...)
+	command.Dir = g.checkoutDir()
+	command.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1")
+	var stdout, stderr bytes.Buffer
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], message)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
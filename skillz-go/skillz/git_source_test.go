@@ -0,0 +1,220 @@
+package skillz
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit runs git in dir with a fixed identity and returns trimmed stdout.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+	)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commitSkill writes a skill under skills/ in the work tree and commits it.
+func commitSkill(t *testing.T, work string, name string, body string) string {
+	t.Helper()
+	dir := filepath.Join(work, "skills", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: " + name + "\ndescription: Skill from git\n---\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, SkillMarkdown), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", "-A")
+	runGit(t, work, "commit", "--quiet", "-m", "update "+name)
+	return runGit(t, work, "rev-parse", "HEAD")
+}
+
+func newGitRemote(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	work := t.TempDir()
+	runGit(t, work, "init", "--quiet", "--initial-branch=main")
+	return work, "file://" + filepath.ToSlash(work)
+}
+
+func TestGitSourceFollowsRefAndRecordsCommit(t *testing.T) {
+	work, remote := newGitRemote(t)
+	first := commitSkill(t, work, "git-skill", "First")
+
+	source, err := NewGitSource(GitSourceOptions{Repository: remote, Ref: "main", Subdir: "skills", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	registry := NewRegistry("")
+	registry.Sources = []Source{source}
+	if changed, err := SyncSources(context.Background(), registry); err != nil || !changed {
+		t.Fatalf("first sync: changed=%v err=%v", changed, err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	skill, err := registry.Get("git-skill")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if skill.Revision != first || skill.Origin != source.Name() || !strings.Contains(skill.Instructions, "First") {
+		t.Fatalf("unexpected skill: %+v", skill)
+	}
+
+	if changed, err := source.Sync(context.Background()); err != nil || changed {
+		t.Fatalf("unchanged sync: changed=%v err=%v", changed, err)
+	}
+
+	second := commitSkill(t, work, "git-skill", "Second")
+	if changed, err := source.Sync(context.Background()); err != nil || !changed {
+		t.Fatalf("sync after ref moved: changed=%v err=%v", changed, err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	skill, _ = registry.Get("git-skill")
+	if skill.Revision != second || !strings.Contains(skill.Instructions, "Second") {
+		t.Fatalf("expected reloaded skill at %s, got %+v", second, skill)
+	}
+}
+
+func TestGitSourceKeepsServedWorktreeUntilReload(t *testing.T) {
+	work, remote := newGitRemote(t)
+	first := commitSkill(t, work, "git-skill", "First")
+	source, err := NewGitSource(GitSourceOptions{Repository: remote, Ref: "main", Subdir: "skills", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	source.cache.grace = 0
+	registry := NewRegistry("")
+	registry.Lazy = true
+	registry.Sources = []Source{source}
+	if _, err := SyncSources(context.Background(), registry); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	served := source.Dir()
+
+	// Syncing a new commit leaves the worktree being served untouched.
+	commitSkill(t, work, "git-skill", "Second")
+	if changed, err := source.Sync(context.Background()); err != nil || !changed {
+		t.Fatalf("sync after ref moved: changed=%v err=%v", changed, err)
+	}
+	skill, err := registry.Resolve("git-skill")
+	if err != nil || !strings.Contains(skill.Instructions, "First") || skill.Revision != first {
+		t.Fatalf("expected the served skill to stay at the first commit, got %q at %s: %v", skill.Instructions, skill.Revision, err)
+	}
+
+	if err := registry.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	skill, err = registry.Resolve("git-skill")
+	if err != nil || !strings.Contains(skill.Instructions, "Second") {
+		t.Fatalf("expected the reloaded skill at the second commit, got %q: %v", skill.Instructions, err)
+	}
+	if _, err := os.Stat(served); !os.IsNotExist(err) {
+		t.Fatalf("expected the superseded worktree to be removed after the reload, got %v", err)
+	}
+}
+
+func TestGitSourcesSharingACacheKeepEachOthersWorktrees(t *testing.T) {
+	work, remote := newGitRemote(t)
+	pinned := commitSkill(t, work, "git-skill", "Pinned")
+	cacheDir := t.TempDir()
+	older, err := NewGitSource(GitSourceOptions{Repository: remote, Commit: pinned, Subdir: "skills", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := older.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// A second process follows the branch to a later commit and prunes.
+	commitSkill(t, work, "git-skill", "Later")
+	newer, err := NewGitSource(GitSourceOptions{Repository: remote, Ref: "main", Subdir: "skills", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	newer.cache.grace = 0
+	if _, err := newer.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := newer.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(older.Dir(), "git-skill", SkillMarkdown))
+	if err != nil || !strings.Contains(string(data), "Pinned") {
+		t.Fatalf("expected the first process's worktree to survive, got %q %v", data, err)
+	}
+	if newer.Revision() == pinned {
+		t.Fatalf("expected the second process to follow the branch")
+	}
+}
+
+func TestGitSourcePinsCommit(t *testing.T) {
+	work, remote := newGitRemote(t)
+	pinned := commitSkill(t, work, "git-skill", "Pinned")
+	commitSkill(t, work, "git-skill", "Later")
+
+	source, err := NewGitSource(GitSourceOptions{Repository: remote, Commit: pinned, Subdir: "skills", CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := source.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if source.Revision() != pinned {
+		t.Fatalf("expected pinned revision %s, got %s", pinned, source.Revision())
+	}
+	data, err := os.ReadFile(filepath.Join(source.Dir(), "git-skill", SkillMarkdown))
+	if err != nil || !strings.Contains(string(data), "Pinned") {
+		t.Fatalf("expected pinned content, got %q %v", data, err)
+	}
+}
+
+func TestGitSourceKeepsCheckoutWhenRemoteUnavailable(t *testing.T) {
+	work, remote := newGitRemote(t)
+	commitSkill(t, work, "git-skill", "Body")
+	cacheDir := t.TempDir()
+	source, err := NewGitSource(GitSourceOptions{Repository: remote, Subdir: "skills", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := source.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := os.RemoveAll(work); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewGitSource(GitSourceOptions{Repository: remote, Subdir: "skills", CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if _, err := restarted.Sync(context.Background()); err == nil {
+		t.Fatal("expected fetch failure")
+	}
+	if restarted.Revision() != source.Revision() {
+		t.Fatalf("expected revision %s to survive restart, got %s", source.Revision(), restarted.Revision())
+	}
+	if _, err := os.Stat(filepath.Join(restarted.Dir(), "git-skill", SkillMarkdown)); err != nil {
+		t.Fatalf("checkout lost: %v", err)
+	}
+}
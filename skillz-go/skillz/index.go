@@ -0,0 +1,218 @@
+package skillz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// discoveryIndexVersion is bumped whenever the index format or the meaning of
+// its entries changes; indexes of other versions are discarded.
+const discoveryIndexVersion = 2
+
+// discoveryIndex is the on-disk cache of parsed candidates that lets Load
+// skip parsing skills that have not changed since the previous run.
+type discoveryIndex struct {
+	Version int                    `json:"version"`
+	Entries map[string]*indexEntry `json:"entries"`
+}
+
+// indexEntry records what parsing one candidate produced, together with the
+// size and modification time it was parsed at.
+type indexEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	// SHA256 is the digest of an archive candidate. An archive whose size
+	// or modification time changed but whose digest did not, such as one
+	// downloaded again, is not parsed again.
+	SHA256   string        `json:"sha256,omitempty"`
+	Metadata SkillMetadata `json:"metadata"`
+	// BodyOffset is where the body starts in SKILL.md, before its leading
+	// whitespace is trimmed.
+	BodyOffset int64 `json:"body_offset"`
+	// Resources lists the resources of the skill, or is nil when they have
+	// not been enumerated.
+	Resources []string `json:"resources"`
+	// Directories records the modification times directoryTimes reported
+	// when the resources of a directory skill were listed. The list is
+	// reused only while they are unchanged.
+	Directories map[string]time.Time `json:"directories,omitempty"`
+}
+
+func newDiscoveryIndex() *discoveryIndex {
+	return &discoveryIndex{Version: discoveryIndexVersion, Entries: map[string]*indexEntry{}}
+}
+
+// readDiscoveryIndex loads the index at path. A missing, unreadable or
+// outdated index yields an empty one, so the next Load rebuilds it.
+func (r *Registry) readDiscoveryIndex() *discoveryIndex {
+	if r.IndexPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.IndexPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			r.logger().Warn("ignoring discovery index", slog.String("path", r.IndexPath), slog.String("error", err.Error()))
+		}
+		return newDiscoveryIndex()
+	}
+	index := newDiscoveryIndex()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers in front matter keep their original text instead of becoming
+	// float64.
+	decoder.UseNumber()
+	if err := decoder.Decode(index); err != nil || index.Version != discoveryIndexVersion || index.Entries == nil {
+		r.logger().Debug("rebuilding discovery index", slog.String("path", r.IndexPath))
+		return newDiscoveryIndex()
+	}
+	return index
+}
+
+// writeDiscoveryIndex replaces the index at path atomically, so concurrent
+// processes never read a partial index.
+func (r *Registry) writeDiscoveryIndex(index *discoveryIndex) error {
+	encoded, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.IndexPath, encoded)
+}
+
+func indexKey(source Source, candidate string) string {
+	return source.Name() + "\x00" + candidate
+}
+
+// lookup returns the entry for a candidate if it is still valid for info, or
+// nil. Archives whose size or modification time changed are validated by
+// digest. The resources of a directory skill are dropped from its entry when
+// one of its directories changed. updated reports that the returned entry
+// differs from the stored one.
+func (index *discoveryIndex) lookup(source Source, candidate string, info ResourceInfo) (entry *indexEntry, updated bool) {
+	if index == nil {
+		return nil, false
+	}
+	entry, ok := index.Entries[indexKey(source, candidate)]
+	if !ok {
+		return nil, false
+	}
+	if entry.Size == info.Size && entry.ModTime.Equal(info.ModTime) {
+		if entry.Directories != nil && !directoriesUnchanged(source, candidate, entry.Directories) {
+			unlisted := *entry
+			unlisted.Resources, unlisted.Directories = nil, nil
+			return &unlisted, true
+		}
+		return entry, false
+	}
+	if entry.SHA256 == "" {
+		return nil, false
+	}
+	digest, err := archiveDigest(source, candidate)
+	if err != nil || digest != entry.SHA256 {
+		return nil, false
+	}
+	revalidated := *entry
+	revalidated.Size, revalidated.ModTime = info.Size, info.ModTime
+	return &revalidated, true
+}
+
+// newIndexEntry records the outcome of parsing a candidate. It returns nil
+// for candidates that cannot be validated on the next run: those without a
+// modification time, such as embedded skills, and those whose front matter
+// does not survive a round trip through JSON.
+func newIndexEntry(source Source, candidate string, info ResourceInfo, raw string, skill Skill, resources []ResourceInfo) *indexEntry {
+	if info.ModTime.IsZero() {
+		return nil
+	}
+	if _, err := json.Marshal(skill.Metadata); err != nil {
+		return nil
+	}
+	entry := &indexEntry{
+		Size:       info.Size,
+		ModTime:    info.ModTime,
+		Metadata:   skill.Metadata,
+		BodyOffset: int64(len(raw) - len(skill.Instructions)),
+	}
+	if isArchiveName(candidate) {
+		digest, err := archiveDigest(source, candidate)
+		if err != nil {
+			return nil
+		}
+		entry.SHA256 = digest
+	}
+	entry.setResources(source, candidate, resources)
+	return entry
+}
+
+func (entry *indexEntry) setResources(source Source, candidate string, resources []ResourceInfo) {
+	if resources == nil {
+		return
+	}
+	entry.Directories = directoryTimes(source, candidate, resources)
+	entry.Resources = make([]string, 0, len(resources))
+	for _, resource := range resources {
+		entry.Resources = append(entry.Resources, resource.Path)
+	}
+}
+
+func (entry *indexEntry) resources() []ResourceInfo {
+	resources := make([]ResourceInfo, 0, len(entry.Resources))
+	for _, resourcePath := range entry.Resources {
+		resources = append(resources, ResourceInfo{Path: resourcePath})
+	}
+	return resources
+}
+
+// readSkillBody reads the body of a candidate's SKILL.md from offset, without
+// parsing its front matter again.
+func readSkillBody(source Source, candidate string, offset int64) (string, error) {
+	reader, err := source.OpenSkillMarkdown(candidate)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	if _, err := io.CopyN(io.Discard, reader, offset); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(string(data), " \t\r\n"), nil
+}
+
+// archiveDigest returns the SHA-256 of an archive candidate, or an error for
+// sources that cannot read their archives directly.
+func archiveDigest(source Source, candidate string) (string, error) {
+	digester, ok := source.(interface {
+		archiveDigest(candidate string) (string, error)
+	})
+	if !ok {
+		return "", errors.ErrUnsupported
+	}
+	return digester.archiveDigest(candidate)
+}
+
+func (s *fsSource) archiveDigest(candidate string) (string, error) {
+	name, err := s.fsPath(candidate)
+	if err != nil {
+		return "", err
+	}
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,211 @@
+package skillz
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readIndexFile(t *testing.T, indexPath string) *discoveryIndex {
+	t.Helper()
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	index := newDiscoveryIndex()
+	if err := json.Unmarshal(data, index); err != nil {
+		t.Fatalf("decode index: %v", err)
+	}
+	return index
+}
+
+func writeIndexFile(t *testing.T, indexPath string, index *discoveryIndex) {
+	t.Helper()
+	data, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("encode index: %v", err)
+	}
+	if err := os.WriteFile(indexPath, data, 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+}
+
+// tamperIndex rewrites the description of every indexed skill, so tests can
+// tell skills restored from the index from skills parsed again.
+func tamperIndex(t *testing.T, indexPath string) {
+	t.Helper()
+	index := readIndexFile(t, indexPath)
+	for _, entry := range index.Entries {
+		entry.Metadata.Description = "from index"
+	}
+	writeIndexFile(t, indexPath, index)
+}
+
+func loadIndexed(t *testing.T, root string, indexPath string, lazy bool) *Registry {
+	t.Helper()
+	registry := NewRegistry(root)
+	registry.Lazy = lazy
+	registry.IndexPath = indexPath
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return registry
+}
+
+func TestDiscoveryIndexMatchesFullParse(t *testing.T) {
+	temp := t.TempDir()
+	generateSkillTree(t, temp, 120)
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+
+	skills := func(registry *Registry) []Skill {
+		skills := registry.Skills()
+		for index := range skills {
+			skills[index].MetadataLoadTime = 0
+		}
+		return skills
+	}
+	// The lazy pass leaves archive resources out of the index, so the second
+	// pass must enumerate and add them.
+	for _, lazy := range []bool{true, false} {
+		parsed := skills(loadIndexed(t, temp, "", lazy))
+		skills(loadIndexed(t, temp, indexPath, lazy))
+		if indexed := skills(loadIndexed(t, temp, indexPath, lazy)); !reflect.DeepEqual(parsed, indexed) {
+			t.Fatalf("lazy=%v: skills restored from the index differ from parsed skills", lazy)
+		}
+	}
+	if entries := len(readIndexFile(t, indexPath).Entries); entries != 120 {
+		t.Fatalf("expected an entry per candidate, got %d", entries)
+	}
+}
+
+func TestDiscoveryIndexReusesUnchangedSkills(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	writeSkill(t, temp, "edited")
+	createZipSkill(t, filepath.Join(temp, "packed.zip"), "packed")
+	indexPath := filepath.Join(t.TempDir(), "skillz", "index.json")
+
+	loadIndexed(t, temp, indexPath, false)
+	tamperIndex(t, indexPath)
+
+	edited := filepath.Join(temp, "edited", SkillMarkdown)
+	if err := os.WriteFile(edited, []byte("---\nname: edited\ndescription: Edited skill\n---\nNew body\n"), 0o644); err != nil {
+		t.Fatalf("edit skill: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(edited, later, later); err != nil {
+		t.Fatalf("touch skill: %v", err)
+	}
+	// A rewritten archive with the same content is revalidated by digest.
+	if err := os.Chtimes(filepath.Join(temp, "packed.zip"), later, later); err != nil {
+		t.Fatalf("touch archive: %v", err)
+	}
+
+	registry := loadIndexed(t, temp, indexPath, false)
+	for slug, description := range map[string]string{"echo": "from index", "packed": "from index", "edited": "Edited skill"} {
+		skill, err := registry.Get(slug)
+		if err != nil {
+			t.Fatalf("get %s: %v", slug, err)
+		}
+		if skill.Metadata.Description != description {
+			t.Fatalf("%s: expected description %q, got %q", slug, description, skill.Metadata.Description)
+		}
+	}
+	edit, _ := registry.Get("edited")
+	if edit.Instructions != "New body\n" {
+		t.Fatalf("expected the edited body, got %q", edit.Instructions)
+	}
+	packed, _ := registry.Get("packed")
+	if packed.Instructions != "Zip Body\n" || !packed.HasResource("text/hello.txt") {
+		t.Fatalf("expected the archive body and resources, got %q %v", packed.Instructions, packed.Resources)
+	}
+
+	for key, entry := range readIndexFile(t, indexPath).Entries {
+		touched := filepath.Base(key) == "packed.zip" || filepath.Base(key) == "edited"
+		if touched && !entry.ModTime.After(time.Now()) {
+			t.Fatalf("expected the index entry of %s to be updated", filepath.Base(key))
+		}
+	}
+}
+
+func TestDiscoveryIndexDropsRemovedSkills(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	removed := writeSkill(t, temp, "removed")
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+
+	loadIndexed(t, temp, indexPath, true)
+	if err := os.RemoveAll(removed); err != nil {
+		t.Fatalf("remove skill: %v", err)
+	}
+	registry := loadIndexed(t, temp, indexPath, true)
+	if _, err := registry.Get("removed"); err == nil {
+		t.Fatalf("expected removed skill to be gone")
+	}
+	if entries := len(readIndexFile(t, indexPath).Entries); entries != 1 {
+		t.Fatalf("expected the removed skill to leave the index, got %d entries", entries)
+	}
+}
+
+func TestDiscoveryIndexIgnoresCorruptIndex(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(indexPath, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+
+	registry := loadIndexed(t, temp, indexPath, false)
+	if _, err := registry.Get("echo"); err != nil {
+		t.Fatalf("get skill: %v", err)
+	}
+	if entries := len(readIndexFile(t, indexPath).Entries); entries != 1 {
+		t.Fatalf("expected the index to be rebuilt, got %d entries", entries)
+	}
+
+	tamperIndex(t, indexPath)
+	stale := readIndexFile(t, indexPath)
+	stale.Version = discoveryIndexVersion + 1
+	writeIndexFile(t, indexPath, stale)
+	registry = loadIndexed(t, temp, indexPath, false)
+	if skill, _ := registry.Get("echo"); skill.Metadata.Description != "Test skill" {
+		t.Fatalf("expected an index of another version to be ignored, got %q", skill.Metadata.Description)
+	}
+}
+
+func TestDiscoveryIndexListsDirectoryResourcesOnlyWhenChanged(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "docs", withFile("guides/first.md", "first"))
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	loadIndexed(t, temp, indexPath, false)
+
+	// Resources recorded in the index are served without listing the skill.
+	tamperIndex(t, indexPath)
+	index := readIndexFile(t, indexPath)
+	for _, entry := range index.Entries {
+		entry.Resources = append(entry.Resources, "from-index.md")
+	}
+	writeIndexFile(t, indexPath, index)
+	registry := loadIndexed(t, temp, indexPath, false)
+	if skill, _ := registry.Get("docs"); !skill.HasResource("from-index.md") {
+		t.Fatalf("expected resources from the index, got %v", skill.Resources)
+	}
+
+	// A file added to a nested directory changes its time, so the skill is
+	// listed again while its front matter still comes from the index.
+	if err := os.WriteFile(filepath.Join(dir, "guides", "second.md"), []byte("second"), 0o644); err != nil {
+		t.Fatalf("write resource: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "guides"), later, later); err != nil {
+		t.Fatalf("touch directory: %v", err)
+	}
+	registry = loadIndexed(t, temp, indexPath, false)
+	skill, _ := registry.Get("docs")
+	if !skill.HasResource("guides/second.md") || skill.HasResource("from-index.md") || skill.Metadata.Description != "from index" {
+		t.Fatalf("expected the listed resources and indexed front matter, got %v %q", skill.Resources, skill.Metadata.Description)
+	}
+}
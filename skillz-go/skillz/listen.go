@@ -0,0 +1,67 @@
+package skillz
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultSocketMode os.FileMode = 0o600
+
+// listen opens the listener for the http and sse transports. Listen accepts
+// "unix:///path/to.sock", "tcp://host:port" or "host:port"; when it is empty
+// Host and Port are used.
+func listen(options RunOptions) (net.Listener, error) {
+	address := strings.TrimSpace(options.Listen)
+	if address == "" {
+		return net.Listen("tcp", fmt.Sprintf("%s:%d", options.Host, options.Port))
+	}
+	if socketPath, ok := strings.CutPrefix(address, "unix://"); ok {
+		return listenUnix(socketPath, options.SocketMode)
+	}
+	return net.Listen("tcp", strings.TrimPrefix(address, "tcp://"))
+}
+
+func listenUnix(socketPath string, mode os.FileMode) (net.Listener, error) {
+	if socketPath == "" {
+		return nil, errors.New("unix listen address is missing a socket path")
+	}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if mode == 0 {
+		mode = defaultSocketMode
+	}
+	if err := os.Chmod(socketPath, mode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("set permissions on %s: %w", socketPath, err)
+	}
+	return listener, nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a previous process,
+// refusing to touch regular files or sockets that still accept connections.
+func removeStaleSocket(socketPath string) error {
+	stat, err := os.Lstat(socketPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stat.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is already in use", socketPath)
+	}
+	return os.Remove(socketPath)
+}
@@ -0,0 +1,75 @@
+package skillz
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "skillz.sock")
+	listener, err := listen(RunOptions{Listen: "unix://" + socketPath, SocketMode: 0o660})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	go func() { _ = httpServer.Serve(listener) }()
+	defer httpServer.Close()
+
+	stat, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if stat.Mode().Perm() != 0o660 {
+		t.Fatalf("unexpected socket mode: %v", stat.Mode().Perm())
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	response, err := client.Get("http://skillz/mcp")
+	if err != nil {
+		t.Fatalf("request over socket: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+
+	if _, err := listen(RunOptions{Listen: "unix://" + socketPath}); err == nil {
+		t.Fatalf("expected in-use socket to be rejected")
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "stale.sock")
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	listener, err := listen(RunOptions{Listen: "unix://" + socketPath})
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced: %v", err)
+	}
+	_ = listener.Close()
+
+	regular := filepath.Join(t.TempDir(), "regular")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := listen(RunOptions{Listen: "unix://" + regular}); err == nil {
+		t.Fatalf("expected regular file to be refused")
+	}
+}
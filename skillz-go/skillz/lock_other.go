@@ -0,0 +1,20 @@
+//go:build !unix
+
+package skillz
+
+import "os"
+
+// File locks are only taken on Unix. Elsewhere processes sharing a cache or
+// an audit log are not coordinated, and each must use its own.
+
+func lockFile(file *os.File, exclusive bool) error {
+	return nil
+}
+
+func tryLockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,202 @@
+package skillz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type LogOptions struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is text or json.
+	Format string
+	// File, when set, receives the log instead of stderr. It is rotated once it
+	// grows beyond MaxSizeBytes, keeping MaxBackups old files.
+	File         string
+	MaxSizeBytes int64
+	MaxBackups   int
+}
+
+// NewLogger builds a structured logger. Logs never go to stdout, which
+// carries the protocol for the stdio transport. The returned closer releases
+// the log file, if any.
+func NewLogger(options LogOptions) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(options.Level))); err != nil && options.Level != "" {
+		return nil, nil, fmt.Errorf("invalid log level %q", options.Level)
+	}
+
+	var writer io.Writer = os.Stderr
+	var closer io.Closer = stderrCloser{}
+	if options.File != "" {
+		file, err := openRotatingFile(options.File, options.MaxSizeBytes, options.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		writer, closer = file, file
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(strings.TrimSpace(options.Format)) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(writer, handlerOptions)), closer, nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(writer, handlerOptions)), closer, nil
+	default:
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("invalid log format %q", options.Format)
+	}
+}
+
+// stderrCloser is the closer of a logger writing to stderr, which is left
+// open.
+type stderrCloser struct{}
+
+func (stderrCloser) Close() error {
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// rotatingFile is an append-only log file that is renamed to path.1 (shifting
+// older backups) once a write would take it past maxSize.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rotating := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rotating.open(); err != nil {
+		return nil, err
+	}
+	return rotating, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size = file, stat.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	written, err := f.file.Write(p)
+	f.size += int64(written)
+	return written, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxBackups))
+	for index := f.maxBackups - 1; index >= 1; index-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", f.path, index), fmt.Sprintf("%s.%d", f.path, index+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+func requestLogServerOptions(logger *slog.Logger, registry *Registry, options ServerOptions) []server.ServerOption {
+	toolMiddleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			started := time.Now()
+			result, err := next(ctx, request)
+			attributes := []slog.Attr{
+				slog.String("tool", request.Params.Name),
+				slog.String("caller", callerIdentity(ctx).Subject),
+			}
+			call := options.classifyToolCall(registry, request)
+			if call.skill != "" {
+				attributes = append(attributes, slog.String("skill", call.skill))
+			}
+			if call.kind == fetchResourceCall {
+				outcome, bytes := fetchResultSummary(result, err)
+				attributes = append(attributes,
+					slog.String("uri", call.uri),
+					slog.Int("bytes", bytes),
+					slog.String("outcome", outcome),
+				)
+			}
+			attributes = append(attributes, slog.Duration("duration", time.Since(started)))
+			switch {
+			case err != nil:
+				logger.LogAttrs(ctx, slog.LevelWarn, "tool call failed", append(attributes, slog.String("error", err.Error()))...)
+			case result != nil && result.IsError:
+				logger.LogAttrs(ctx, slog.LevelInfo, "tool call returned error", attributes...)
+			default:
+				logger.LogAttrs(ctx, slog.LevelInfo, "tool call", attributes...)
+			}
+			return result, err
+		}
+	}
+	resourceMiddleware := func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			started := time.Now()
+			contents, err := next(ctx, request)
+			read := classifyResourceRead(registry, request.Params.URI)
+			attributes := []slog.Attr{
+				slog.String("skill", read.skill),
+				slog.String("uri", request.Params.URI),
+				slog.String("caller", callerIdentity(ctx).Subject),
+				slog.Int("bytes", resourceContentBytes(contents)),
+				slog.Duration("duration", time.Since(started)),
+			}
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelWarn, "resource read failed", append(attributes, slog.String("error", err.Error()))...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "resource read", attributes...)
+			}
+			return contents, err
+		}
+	}
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(toolMiddleware),
+		server.WithResourceHandlerMiddleware(resourceMiddleware),
+	}
+}
@@ -0,0 +1,124 @@
+package skillz
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeLogLines(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestRegistryLogsSkippedSkills(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, filepath.Join(temp, "a"), "Dup Skill")
+	writeSkill(t, filepath.Join(temp, "b"), "Dup Skill")
+	broken := filepath.Join(temp, "c")
+	if err := os.MkdirAll(broken, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(broken, SkillMarkdown), []byte("no front matter"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	registry := NewRegistry(temp)
+	registry.Logger = slog.New(slog.NewJSONHandler(&buffer, nil))
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	reasons := map[string]bool{}
+	for _, entry := range decodeLogLines(t, &buffer) {
+		if entry["msg"] == "skipping skill" {
+			reasons[entry["reason"].(string)] = true
+		}
+	}
+	if !reasons["duplicate_slug"] || !reasons["invalid_skill"] {
+		t.Fatalf("expected duplicate and invalid skips, got %v:\n%s", reasons, buffer.String())
+	}
+}
+
+func TestServerLogsToolCallsAndResourceReads(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var buffer bytes.Buffer
+	mcpServer := BuildMCPServer(registry, ServerOptions{Logger: slog.New(slog.NewJSONHandler(&buffer, nil))})
+
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/script.py"},
+	})
+	callServer(t, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/data.bin"})
+
+	lines := decodeLogLines(t, &buffer)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got:\n%s", buffer.String())
+	}
+	fetch, read := lines[0], lines[1]
+	if fetch["msg"] != "tool call" || fetch["skill"] != "testskill" || fetch["bytes"] != float64(14) || fetch["outcome"] != "ok" {
+		t.Fatalf("unexpected fetch log: %v", fetch)
+	}
+	if _, ok := fetch["duration"]; !ok {
+		t.Fatalf("missing duration: %v", fetch)
+	}
+	if read["msg"] != "resource read" || read["uri"] != "resource://skillz/testskill/data.bin" || read["bytes"] != float64(6) {
+		t.Fatalf("unexpected read log: %v", read)
+	}
+}
+
+func TestRotatingFileKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skillz.log")
+	file, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first----\n", "second---\n", "third----\n", "fourth---\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for suffix, expected := range map[string]string{"": "fourth---\n", ".1": "third----\n", ".2": "second---\n"} {
+		data, err := os.ReadFile(path + suffix)
+		if err != nil || string(data) != expected {
+			t.Fatalf("%s%s = %q, %v; want %q", path, suffix, data, err, expected)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only two backups, stat .3: %v", err)
+	}
+}
+
+func TestNewLoggerRejectsUnknownFormat(t *testing.T) {
+	if _, _, err := NewLogger(LogOptions{Format: "xml"}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	if _, _, err := NewLogger(LogOptions{Level: "loud"}); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
@@ -0,0 +1,656 @@
+package skillz
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const serverName = "Skillz MCP Server"
+const serverVersion = "0.1.0-go"
+
+type ServerOptions struct {
+	// MaxPayloadBytes caps the content returned by fetch_resource and resource
+	// reads. Zero means defaultMaxPayloadBytes.
+	MaxPayloadBytes int64
+	// Policy restricts which skills each caller can list, invoke and read.
+	// A nil policy exposes every skill.
+	Policy *Policy
+	// Metrics, when set, records tool calls and resource reads.
+	Metrics *Metrics
+	// Usage, when set, appends every tool call and resource read to a usage
+	// log for later reporting with SummarizeUsage.
+	Usage *UsageLog
+	// Audit, when set, records every skill tool call, search and resource read
+	// in a hash-chained audit log.
+	Audit *AuditLog
+	// Tracer, when set, records a span for each tool call and resource read.
+	Tracer *Tracer
+	// Logger, when set, receives one line per tool call and resource read.
+	Logger *slog.Logger
+	// Filter, when set, limits the server to the skills it returns true for;
+	// other skills are neither listed nor readable.
+	Filter func(Skill) bool
+	// Exposure selects the MCP surfaces skills are published on. Zero means
+	// ExposeAll.
+	Exposure Exposure
+	// Hooks, when set, receives the hooks skillz relies on so that a host
+	// server can keep its own hooks. Nil means skillz installs its own.
+	// BuildMCPServer adds its hooks to a copy, so options can be shared by
+	// several servers.
+	Hooks *server.Hooks
+	// Middleware runs around skill invocations and resource reads.
+	Middleware []Middleware
+}
+
+func (o ServerOptions) resourceInterceptor(ctx context.Context, method string) *resourceInterceptor {
+	if len(o.Middleware) == 0 {
+		return nil
+	}
+	return &resourceInterceptor{ctx: ctx, chain: o.Middleware, method: method}
+}
+
+func (o ServerOptions) allows(ctx context.Context, skill Skill) bool {
+	if o.Filter != nil && !o.Filter(skill) {
+		return false
+	}
+	return o.Policy.Allows(callerIdentity(ctx), skill)
+}
+
+// visibleSkills lists the skills of registry the caller in ctx may see.
+func (o ServerOptions) visibleSkills(ctx context.Context, registry *Registry) []Skill {
+	visible := []Skill{}
+	for _, skill := range registry.Skills() {
+		if o.allows(ctx, skill) {
+			visible = append(visible, skill)
+		}
+	}
+	return visible
+}
+
+// requestKind classifies the tool calls and resource reads the server
+// middleware observes.
+type requestKind int
+
+const (
+	// unknownTool is a tool skillz does not serve, such as a host tool.
+	unknownTool requestKind = iota
+	fetchResourceCall
+	searchResourcesCall
+	listSkillFilesCall
+	skillToolCall
+	resourceRead
+)
+
+// classifiedRequest describes a tool call or resource read for the server
+// middleware: what kind it is, the skill and resource it concerns, and whether
+// that skill exists.
+type classifiedRequest struct {
+	kind     requestKind
+	tool     string
+	skill    string
+	resource string
+	uri      string
+	known    bool
+}
+
+// classifyToolCall is the one place that decides which tool a call reaches,
+// so tracing, metrics, audit, usage and logging agree.
+func (o ServerOptions) classifyToolCall(registry *Registry, call mcp.CallToolRequest) classifiedRequest {
+	classified := classifiedRequest{kind: unknownTool, tool: call.Params.Name}
+	helpers := o.Exposure.has(ExposeHelperTools)
+	switch name := call.Params.Name; {
+	case name == "fetch_resource" && helpers:
+		classified.kind = fetchResourceCall
+		classified.uri = call.GetString("resource_uri", "")
+		classified.skill, classified.resource, _ = parseResourceURI(classified.uri)
+	case name == "search_resources" && helpers:
+		classified.kind = searchResourcesCall
+		classified.skill = strings.TrimSpace(call.GetString("skill", ""))
+	case name == "list_skill_files" && helpers:
+		classified.kind = listSkillFilesCall
+		classified.skill = strings.TrimSpace(call.GetString("slug", ""))
+	default:
+		if _, ok := o.skillTool(registry, name); ok {
+			classified.kind = skillToolCall
+			classified.skill = name
+		}
+	}
+	classified.known = knownSkill(registry, classified.skill)
+	return classified
+}
+
+func classifyResourceRead(registry *Registry, uri string) classifiedRequest {
+	classified := classifiedRequest{kind: resourceRead, uri: uri}
+	classified.skill, classified.resource, _ = parseResourceURI(uri)
+	classified.known = knownSkill(registry, classified.skill)
+	return classified
+}
+
+func knownSkill(registry *Registry, slug string) bool {
+	if slug == "" {
+		return false
+	}
+	_, err := registry.Get(slug)
+	return err == nil
+}
+
+// skillTool returns the skill served by the tool called name when skillz
+// registers that tool: skill tools are exposed and the skill exists and passes
+// Filter. Host tools that merely share a name with a skill are not skill
+// tools.
+func (o ServerOptions) skillTool(registry *Registry, name string) (Skill, bool) {
+	if !o.Exposure.has(ExposeSkillTools) {
+		return Skill{}, false
+	}
+	skill, err := registry.Get(name)
+	if err != nil || (o.Filter != nil && !o.Filter(skill)) {
+		return Skill{}, false
+	}
+	return skill, true
+}
+
+// BuildMCPServer creates a standalone skillz server. Hosts adding skills to a
+// server of their own use ServerMiddleware and Mount instead.
+func BuildMCPServer(registry *Registry, options ServerOptions) *server.MCPServer {
+	if options.Hooks == nil {
+		options.Hooks = &server.Hooks{}
+	} else {
+		options.Hooks = cloneHooks(options.Hooks)
+	}
+	// Instructions are rebuilt per session so they track registry reloads and
+	// only mention skills the caller may see.
+	options.Hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		result.Instructions = buildServerInstructions(options.visibleSkills(ctx, registry))
+	})
+	serverOptions := []server.ServerOption{
+		server.WithInstructions(buildServerInstructions(options.visibleSkills(context.Background(), registry))),
+		server.WithToolCapabilities(true),
+	}
+	if options.Exposure.has(ExposeResources) {
+		serverOptions = append(serverOptions, server.WithResourceCapabilities(true, false))
+	}
+	serverOptions = append(serverOptions, options.middleware(registry)...)
+	mcpServer := server.NewMCPServer(serverName, serverVersion, serverOptions...)
+	mount(mcpServer, registry, options)
+	return mcpServer
+}
+
+// cloneHooks copies hooks with every list clipped to its length, so adding
+// hooks to the copy never writes into the lists of the original.
+func cloneHooks(hooks *server.Hooks) *server.Hooks {
+	clone := *hooks
+	fields := reflect.ValueOf(&clone).Elem()
+	for index := range fields.NumField() {
+		if field := fields.Field(index); field.Kind() == reflect.Slice {
+			field.Set(field.Slice3(0, field.Len(), field.Len()))
+		}
+	}
+	return &clone
+}
+
+// middleware returns the server options that authorize, trace, meter, audit
+// and log requests, and installs the hooks skillz relies on.
+func (o ServerOptions) middleware(registry *Registry) []server.ServerOption {
+	if o.Hooks == nil {
+		o.Hooks = &server.Hooks{}
+	}
+	serverOptions := []server.ServerOption{server.WithHooks(o.Hooks)}
+	if o.Policy != nil || o.Filter != nil {
+		serverOptions = append(serverOptions, authorizationServerOptions(registry, o, o.Hooks)...)
+	}
+	if o.Tracer != nil {
+		serverOptions = append(serverOptions, o.Tracer.serverOptions(registry, o)...)
+	}
+	if o.Metrics != nil {
+		serverOptions = append(serverOptions, o.Metrics.serverOptions(registry, o)...)
+	}
+	if o.Audit != nil {
+		serverOptions = append(serverOptions, o.Audit.serverOptions(registry, o)...)
+	}
+	if o.Usage != nil {
+		serverOptions = append(serverOptions, o.Usage.serverOptions(registry, o)...)
+	}
+	if o.Logger != nil {
+		serverOptions = append(serverOptions, requestLogServerOptions(o.Logger, registry, o)...)
+	}
+	return serverOptions
+}
+
+// mount registers the skillz tools, resources and resource template selected
+// by options.Exposure on mcpServer.
+func mount(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	if options.Exposure.has(ExposeHelperTools) {
+		registerFetchResourceTool(mcpServer, registry, options)
+		registerSearchResourcesTool(mcpServer, registry, options)
+		registerListSkillFilesTool(mcpServer, registry, options)
+	}
+	if registry.Lazy && options.Exposure.has(ExposeResources) {
+		registerSkillResourceTemplate(mcpServer, registry, options)
+	}
+	registerSkills(mcpServer, registry, options)
+}
+
+func registerSkills(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	for _, skill := range registry.Skills() {
+		if options.Filter != nil && !options.Filter(skill) {
+			continue
+		}
+		if !registry.Lazy && options.Exposure.has(ExposeResources) {
+			registerSkillResources(mcpServer, registry, skill, options)
+		}
+		if options.Exposure.has(ExposeSkillTools) {
+			registerSkillTool(mcpServer, registry, skill, options)
+		}
+	}
+}
+
+// RefreshSkills re-registers skill tools and resources on a server built by
+// BuildMCPServer, or mounted with Mount, after registry has been reloaded. previous holds the skills
+// loaded before the reload; their tools and resources are withdrawn first.
+func RefreshSkills(mcpServer *server.MCPServer, registry *Registry, previous []Skill, options ServerOptions) {
+	current := map[string]bool{}
+	for _, skill := range registry.Skills() {
+		current[skill.Slug] = true
+	}
+	removed := []string{}
+	for _, skill := range previous {
+		if !current[skill.Slug] {
+			removed = append(removed, skill.Slug)
+		}
+		if !registry.Lazy {
+			for _, relPath := range sortedKeys(skill.Resources) {
+				mcpServer.RemoveResource(BuildResourceURI(skill, relPath))
+			}
+		}
+	}
+	if len(removed) > 0 {
+		mcpServer.DeleteTools(removed...)
+	}
+	registerSkills(mcpServer, registry, options)
+}
+
+// authorizationServerOptions hides skills the caller may not see from skill
+// tool listings and resource listings.
+func authorizationServerOptions(registry *Registry, options ServerOptions, hooks *server.Hooks) []server.ServerOption {
+	hooks.AddAfterListResources(func(ctx context.Context, id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		visible := make([]mcp.Resource, 0, len(result.Resources))
+		for _, resource := range result.Resources {
+			slug, _, err := parseResourceURI(resource.URI)
+			if err != nil {
+				continue
+			}
+			if skill, err := registry.Get(slug); err == nil && options.allows(ctx, skill) {
+				visible = append(visible, resource)
+			}
+		}
+		result.Resources = visible
+	})
+
+	toolFilter := func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		visible := make([]mcp.Tool, 0, len(tools))
+		for _, tool := range tools {
+			// Only tools skillz registers for skills are hidden; host tools
+			// named like a skill stay listed.
+			if skill, ok := options.skillTool(registry, tool.Name); ok && !options.allows(ctx, skill) {
+				continue
+			}
+			visible = append(visible, tool)
+		}
+		return visible
+	}
+
+	return []server.ServerOption{server.WithToolFilter(toolFilter)}
+}
+
+// resolveAuthorized resolves a skill for the caller, reporting skills hidden by
+// the policy as unknown so their existence is not disclosed.
+func resolveAuthorized(ctx context.Context, registry *Registry, slug string, options ServerOptions) (Skill, error) {
+	skill, err := registry.Get(slug)
+	if err == nil && !options.allows(ctx, skill) {
+		return Skill{}, SkillError{Code: "skill_error", Message: fmt.Sprintf("unknown skill '%s'", slug)}
+	}
+	if err != nil {
+		return Skill{}, err
+	}
+	return registry.Resolve(slug)
+}
+
+func registerFetchResourceTool(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	fetchTool := mcp.NewTool(
+		"fetch_resource",
+		mcp.WithDescription(
+			"[FALLBACK ONLY] Fetch a skill resource by URI. "+
+				"Use this only when native MCP resource fetching is unavailable.",
+		),
+		mcp.WithString("resource_uri", mcp.Description("resource://skillz/{skill-slug}/{path}"), mcp.Required()),
+		mcp.WithNumber("offset", mcp.Description("Byte offset to start reading from; use next_offset from a previous response to continue")),
+		mcp.WithNumber("length", mcp.Description("Maximum number of bytes to return")),
+		mcp.WithNumber("start_line", mcp.Description("First line to return (1-based, text resources)")),
+		mcp.WithNumber("end_line", mcp.Description("Last line to return (inclusive)")),
+	)
+
+	mcpServer.AddTool(fetchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceURI := request.GetString("resource_uri", "")
+		if resourceURI == "" {
+			result := makeErrorResource("(missing)", errorCodeMissingURI, "resource_uri is required")
+			return mcp.NewToolResultStructured(result, "resource_uri is required"), nil
+		}
+
+		fetchOptions := FetchOptions{
+			Offset:    int64(request.GetInt("offset", 0)),
+			Length:    int64(request.GetInt("length", 0)),
+			StartLine: request.GetInt("start_line", 0),
+			EndLine:   request.GetInt("end_line", 0),
+			MaxBytes:  options.MaxPayloadBytes,
+			Authorize: func(skill Skill) bool { return options.allows(ctx, skill) },
+
+			interceptor: options.resourceInterceptor(ctx, "fetch_resource"),
+		}
+		result := FetchResourceJSONWithOptions(registry, resourceURI, fetchOptions)
+		return mcp.NewToolResultStructured(result, "resource fetched"), nil
+	})
+}
+
+func registerSearchResourcesTool(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	searchTool := mcp.NewTool(
+		"search_resources",
+		mcp.WithDescription(
+			"Search the text resources of one or all skills with a regular expression "+
+				"and return matching lines with context, instead of fetching whole files.",
+		),
+		mcp.WithString("pattern", mcp.Description("Regular expression (RE2 syntax) to search for"), mcp.Required()),
+		mcp.WithString("skill", mcp.Description("Skill slug to search; omit to search all skills")),
+		mcp.WithString("glob", mcp.Description("Only search resources matching this glob, e.g. *.md or docs/*.txt")),
+		mcp.WithNumber("max_results", mcp.Description("Maximum number of matching lines to return (default 50)")),
+		mcp.WithNumber("context_lines", mcp.Description("Lines of context before and after each match (default 2)")),
+	)
+
+	mcpServer.AddTool(searchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pattern := request.GetString("pattern", "")
+		if pattern == "" {
+			return nil, errors.New("the 'pattern' parameter must be a non-empty string")
+		}
+		result, err := SearchResources(registry, SearchOptions{
+			Skill:        strings.TrimSpace(request.GetString("skill", "")),
+			Pattern:      pattern,
+			Glob:         strings.TrimSpace(request.GetString("glob", "")),
+			MaxResults:   request.GetInt("max_results", defaultSearchResults),
+			ContextLines: request.GetInt("context_lines", defaultSearchContext),
+			Authorize:    func(skill Skill) bool { return options.allows(ctx, skill) },
+
+			interceptor: options.resourceInterceptor(ctx, "search_resources"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("%d match(es) found", len(result.Matches))), nil
+	})
+}
+
+func registerListSkillFilesTool(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	listTool := mcp.NewTool(
+		"list_skill_files",
+		mcp.WithDescription(
+			"List a skill's resource files as a directory tree with sizes, MIME types, "+
+				"modification times and resource URIs.",
+		),
+		mcp.WithString("slug", mcp.Description("Skill slug"), mcp.Required()),
+		mcp.WithString("path", mcp.Description("Directory (or file) within the skill to list; defaults to the skill root")),
+		mcp.WithNumber("depth", mcp.Description("Number of directory levels to expand; 0 lists the whole tree")),
+	)
+
+	mcpServer.AddTool(listTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slug := strings.TrimSpace(request.GetString("slug", ""))
+		if slug == "" {
+			return nil, errors.New("the 'slug' parameter must be a non-empty string")
+		}
+		if _, err := resolveAuthorized(ctx, registry, slug, options); err != nil {
+			return nil, err
+		}
+		tree, err := ListSkillFiles(registry, slug, request.GetString("path", ""), request.GetInt("depth", 0))
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructured(tree, "skill files listed"), nil
+	})
+}
+
+func registerSkillResources(mcpServer *server.MCPServer, registry *Registry, skill Skill, options ServerOptions) {
+	for _, relPath := range sortedKeys(skill.Resources) {
+		boundRelPath := relPath
+		uri := BuildResourceURI(skill, boundRelPath)
+		name := skill.ResourceName(boundRelPath)
+		mimeType := detectMimeType(boundRelPath)
+
+		resource := mcp.NewResource(
+			uri,
+			name,
+			mcp.WithMIMEType(toOptionalString(mimeType)),
+		)
+
+		mcpServer.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			// Resolve against the current registry rather than the skill
+			// captured at registration, which a reload may have replaced.
+			current, err := resolveAuthorized(ctx, registry, skill.Slug, options)
+			if err != nil || !current.HasResource(boundRelPath) {
+				return nil, fmt.Errorf("resource not found: %s", uri)
+			}
+			return readResourceContents(ctx, current, boundRelPath, uri, options)
+		})
+	}
+}
+
+// registerSkillResourceTemplate exposes all skill resources through a single
+// URI template so that lazy registries never enumerate resources up front.
+func registerSkillResourceTemplate(mcpServer *server.MCPServer, registry *Registry, options ServerOptions) {
+	template := mcp.NewResourceTemplate(
+		"resource://skillz/{slug}/{+path}",
+		"Skill resource",
+		mcp.WithTemplateDescription("A file bundled with a skill, resolved on demand."),
+	)
+
+	mcpServer.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri := request.Params.URI
+		slug, relPath, err := parseResourceURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		skill, err := resolveAuthorized(ctx, registry, slug, options)
+		if err != nil {
+			return nil, err
+		}
+		if !skill.HasResource(relPath) {
+			return nil, fmt.Errorf("resource not found: %s", relPath)
+		}
+		return readResourceContents(ctx, skill, relPath, uri, options)
+	})
+}
+
+// readResourceContents returns a resource for an MCP resource read. Content
+// beyond the payload limit is dropped and a text marker explains how to fetch
+// the rest with fetch_resource.
+func readResourceContents(ctx context.Context, skill Skill, relPath string, uri string, options ServerOptions) ([]mcp.ResourceContents, error) {
+	mimeType := detectMimeType(relPath)
+	interceptor := options.resourceInterceptor(ctx, "resources/read")
+	var read *ResourceRead
+	if interceptor != nil {
+		var err error
+		if read, err = interceptor.before(skill, relPath, uri); err != nil {
+			return nil, err
+		}
+	}
+	chunk, err := readResourceChunk(skill, relPath, FetchOptions{MaxBytes: options.MaxPayloadBytes})
+	if err != nil {
+		return nil, err
+	}
+	data := chunk.data
+	if read != nil {
+		if data, err = interceptor.after(read, data); err != nil {
+			return nil, err
+		}
+	}
+	if utf8Bytes(data) {
+		text := string(data)
+		if chunk.truncated {
+			text += "\n\n" + truncationNotice(chunk)
+		}
+		content := mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: toOptionalString(mimeType),
+			Text:     text,
+		}
+		return []mcp.ResourceContents{content}, nil
+	}
+
+	contents := []mcp.ResourceContents{mcp.BlobResourceContents{
+		URI:      uri,
+		MIMEType: toOptionalString(mimeType),
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	}}
+	if chunk.truncated {
+		contents = append(contents, mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "text/plain",
+			Text:     truncationNotice(chunk),
+		})
+	}
+	return contents, nil
+}
+
+func skillResourceMetadata(skill Skill) []ResourceMetadata {
+	metadata := make([]ResourceMetadata, 0, len(skill.Resources))
+	for _, relPath := range sortedKeys(skill.Resources) {
+		metadata = append(metadata, ResourceMetadata{
+			URI:      BuildResourceURI(skill, relPath),
+			Name:     skill.ResourceName(relPath),
+			MIMEType: detectMimeType(relPath),
+		})
+	}
+	return metadata
+}
+
+func registerSkillTool(mcpServer *server.MCPServer, registry *Registry, skill Skill, options ServerOptions) {
+	tool := mcp.NewTool(
+		skill.Slug,
+		mcp.WithDescription(formatSkillDescription(skill)),
+		mcp.WithString("task", mcp.Description("The user task for this skill"), mcp.Required()),
+		mcp.WithString(
+			"section",
+			mcp.Description("Optional comma-separated section numbers, anchors or headings to return instead of the full instructions"),
+		),
+	)
+
+	mcpServer.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		task := strings.TrimSpace(request.GetString("task", ""))
+		if task == "" {
+			return nil, errors.New("the 'task' parameter must be a non-empty string")
+		}
+
+		skill, err := resolveAuthorized(ctx, registry, skill.Slug, options)
+		if err != nil {
+			return nil, err
+		}
+
+		call := &SkillCall{Skill: skill, Request: request, Caller: CallerFromContext(ctx)}
+		if err := runBefore(ctx, options.Middleware, beforeSkillCall, call); err != nil {
+			return nil, err
+		}
+
+		response := map[string]any{
+			"skill": taskSkillSlug(skill),
+			"task":  task,
+			"metadata": map[string]any{
+				"name":          skill.Metadata.Name,
+				"description":   skill.Metadata.Description,
+				"license":       skill.Metadata.License,
+				"allowed_tools": skill.Metadata.AllowedTools,
+				"extra":         skill.Metadata.Extra,
+			},
+			"resources":    skillResourceMetadata(skill),
+			"instructions": skill.Instructions,
+			"usage":        defaultUsageText(),
+		}
+
+		section := strings.TrimSpace(request.GetString("section", ""))
+		if section != "" || len(skill.Instructions) > maxInlineInstructionBytes {
+			sections := parseMarkdownSections(skill.Instructions)
+			if section != "" {
+				selected, text, err := selectSections(skill.Instructions, sections, section)
+				if err != nil {
+					return nil, err
+				}
+				response["instructions"] = text
+				response["sections"] = selected
+			} else if len(sections) > 0 {
+				response["instructions"] = preamble(skill.Instructions, sections)
+				response["table_of_contents"] = tableOfContents(sections)
+				response["usage"] = sectionUsageText()
+			}
+		}
+
+		call.Response = response
+		if err := runAfter(ctx, options.Middleware, afterSkillCall, call); err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructured(call.Response, "skill instructions returned"), nil
+	})
+}
+
+func buildServerInstructions(skills []Skill) string {
+	names := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		names = append(names, skill.Metadata.Name)
+	}
+	if len(names) == 0 {
+		return "Skillz MCP server exposing local skills as tools and resources."
+	}
+	return fmt.Sprintf(
+		"Skillz MCP server exposing %d skill(s): %s",
+		len(names),
+		strings.Join(names, ", "),
+	)
+}
+
+func formatSkillDescription(skill Skill) string {
+	description := strings.TrimSpace(skill.Metadata.Description)
+	if description == "" {
+		description = "Skill instructions"
+	}
+	return "[SKILL] " + description + " - Invoke this tool to receive specialized instructions and resources for the task."
+}
+
+func defaultUsageText() string {
+	return "Read the skill instructions, retrieve needed resources via MCP resources (or fetch_resource fallback), then apply the guidance to complete the task."
+}
+
+func sectionUsageText() string {
+	return "The instructions are too long to return at once. Review the table of contents, call this tool again with the 'section' argument (number, anchor or heading) for the parts you need, then apply the guidance to complete the task."
+}
+
+func taskSkillSlug(skill Skill) string {
+	return skill.Slug
+}
+
+func toOptionalString(value any) string {
+	if value == nil {
+		return ""
+	}
+	text, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	return text
+}
+
+func utf8Bytes(data []byte) bool {
+	return utf8.Valid(data)
+}
@@ -0,0 +1,183 @@
+package skillz
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func callServer(t *testing.T, mcpServer *server.MCPServer, method string, params any) map[string]any {
+	t.Helper()
+	return callServerWithContext(t, context.Background(), mcpServer, method, params)
+}
+
+func callServerWithContext(t *testing.T, ctx context.Context, mcpServer *server.MCPServer, method string, params any) map[string]any {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	response := mcpServer.HandleMessage(ctx, message)
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	decoded := map[string]any{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if decoded["error"] != nil {
+		t.Fatalf("%s failed: %v", method, decoded["error"])
+	}
+	return decoded["result"].(map[string]any)
+}
+
+func TestLazyServerReadsResourceThroughTemplate(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{})
+
+	listed := callServer(t, mcpServer, "resources/list", map[string]any{})
+	if resources := listed["resources"].([]any); len(resources) != 0 {
+		t.Fatalf("expected no enumerated resources, got %d", len(resources))
+	}
+
+	result := callServer(t, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/script.py"})
+	contents := result["contents"].([]any)
+	if text := contents[0].(map[string]any)["text"]; text != "print('hello')" {
+		t.Fatalf("unexpected contents: %v", text)
+	}
+}
+
+func TestSkillToolReturnsInstructionsAndResources(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{})
+
+	result := callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "testskill",
+		"arguments": map[string]any{"task": "run it"},
+	})
+	structured := result["structuredContent"].(map[string]any)
+	if structured["instructions"] != "Body\n" {
+		t.Fatalf("unexpected instructions: %v", structured["instructions"])
+	}
+	if resources := structured["resources"].([]any); len(resources) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(resources))
+	}
+}
+
+func TestServerFilterAndExposure(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	writeSkill(t, temp, "hidden")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := NewServer(registry,
+		WithFilter(func(skill Skill) bool { return skill.Slug != "hidden" }),
+		WithExposure(ExposeSkillTools|ExposeHelperTools),
+	)
+
+	if mcpServer.GetTool("hidden") != nil || mcpServer.GetTool("testskill") == nil {
+		t.Fatalf("expected only unfiltered skill tools")
+	}
+	called := callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/hidden/notes.txt"},
+	})
+	structured, _ := called["structuredContent"].(map[string]any)
+	if structured["error_code"] != "skill_not_found" {
+		t.Fatalf("expected filtered skill to be unreadable, got %v", called)
+	}
+}
+
+func TestBuildMCPServerLeavesSharedHooksUntouched(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	hooks := &server.Hooks{}
+	initialized := 0
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		initialized++
+	})
+	options := ServerOptions{Hooks: hooks, Policy: writePolicy(t, "default: allow\n")}
+
+	for range 2 {
+		mcpServer := BuildMCPServer(registry, options)
+		result := callServer(t, mcpServer, "initialize", map[string]any{
+			"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+			"clientInfo":      map[string]any{"name": "test", "version": "1.0.0"},
+		})
+		if instructions, _ := result["instructions"].(string); !strings.Contains(instructions, "echo") {
+			t.Fatalf("expected instructions listing the skill, got %q", instructions)
+		}
+	}
+	if len(hooks.OnAfterInitialize) != 1 || len(hooks.OnAfterListResources) != 0 {
+		t.Fatalf("expected the shared hooks to be left alone, got %d initialize and %d list hooks",
+			len(hooks.OnAfterInitialize), len(hooks.OnAfterListResources))
+	}
+	if initialized != 2 {
+		t.Fatalf("expected the host hook to run once per server, ran %d times", initialized)
+	}
+}
+
+func TestClassifyToolCall(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	call := func(name string, arguments map[string]any) mcp.CallToolRequest {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = arguments
+		return request
+	}
+
+	options := ServerOptions{}
+	fetch := options.classifyToolCall(registry, call("fetch_resource", map[string]any{"resource_uri": "resource://skillz/testskill/script.py"}))
+	if fetch.kind != fetchResourceCall || fetch.skill != "testskill" || fetch.resource != "script.py" || !fetch.known {
+		t.Fatalf("unexpected fetch classification: %+v", fetch)
+	}
+	if search := options.classifyToolCall(registry, call("search_resources", map[string]any{"skill": "missing"})); search.kind != searchResourcesCall || search.known {
+		t.Fatalf("unexpected search classification: %+v", search)
+	}
+	if skill := options.classifyToolCall(registry, call("testskill", nil)); skill.kind != skillToolCall || skill.skill != "testskill" {
+		t.Fatalf("unexpected skill classification: %+v", skill)
+	}
+
+	// Tools skillz does not serve are unknown, whatever their name.
+	options.Exposure = ExposeResources
+	for _, name := range []string{"fetch_resource", "testskill", "host_tool"} {
+		if classified := options.classifyToolCall(registry, call(name, nil)); classified.kind != unknownTool || classified.skill != "" {
+			t.Fatalf("expected %s to be unknown, got %+v", name, classified)
+		}
+	}
+}
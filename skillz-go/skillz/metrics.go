@@ -0,0 +1,291 @@
+package skillz
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// unknownLabel replaces label values skillz does not recognise, so clients
+// cannot create series with made-up tool names or skill slugs.
+const unknownLabel = "unknown"
+
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects tool, fetch and resource read statistics and renders them
+// in the Prometheus text exposition format. It has no external dependencies so
+// the binary stays self-contained.
+type Metrics struct {
+	registry *Registry
+
+	mu            sync.Mutex
+	toolCalls     map[string]float64
+	toolDurations map[string]*histogram
+	fetches       map[string]float64
+	reads         map[string]float64
+	bytesServed   map[string]float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func NewMetrics(registry *Registry) *Metrics {
+	return &Metrics{
+		registry:      registry,
+		toolCalls:     map[string]float64{},
+		toolDurations: map[string]*histogram{},
+		fetches:       map[string]float64{},
+		reads:         map[string]float64{},
+		bytesServed:   map[string]float64{},
+	}
+}
+
+func (m *Metrics) observeToolCall(tool string, outcome string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolCalls[labels("tool", tool, "outcome", outcome)]++
+	key := labels("tool", tool)
+	observed, ok := m.toolDurations[key]
+	if !ok {
+		observed = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.toolDurations[key] = observed
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			observed.counts[i]++
+		}
+	}
+	observed.count++
+	observed.sum += seconds
+}
+
+func (m *Metrics) observeFetch(outcome string, skill string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches[labels("outcome", outcome)]++
+	if skill != "" && bytes > 0 {
+		m.bytesServed[labels("skill", skill, "method", "fetch_resource")] += float64(bytes)
+	}
+}
+
+func (m *Metrics) observeRead(skill string, outcome string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads[labels("skill", skill, "outcome", outcome)]++
+	if bytes > 0 {
+		m.bytesServed[labels("skill", skill, "method", "resources_read")] += float64(bytes)
+	}
+}
+
+func (m *Metrics) serverOptions(registry *Registry, options ServerOptions) []server.ServerOption {
+	toolMiddleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			started := time.Now()
+			result, err := next(ctx, request)
+			outcome := "ok"
+			if err != nil || (result != nil && result.IsError) {
+				outcome = "error"
+			}
+			call := options.classifyToolCall(registry, request)
+			m.observeToolCall(call.toolLabel(), outcome, time.Since(started))
+			if call.kind == fetchResourceCall {
+				fetchOutcome, bytes := fetchResultSummary(result, err)
+				skill := ""
+				if fetchOutcome == "ok" {
+					skill = call.skillLabel()
+				}
+				m.observeFetch(fetchOutcome, skill, bytes)
+			}
+			return result, err
+		}
+	}
+	resourceMiddleware := func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			contents, err := next(ctx, request)
+			read := classifyResourceRead(registry, request.Params.URI)
+			if err != nil {
+				m.observeRead(read.skillLabel(), "error", 0)
+			} else {
+				m.observeRead(read.skillLabel(), "ok", resourceContentBytes(contents))
+			}
+			return contents, err
+		}
+	}
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(toolMiddleware),
+		server.WithResourceHandlerMiddleware(resourceMiddleware),
+	}
+}
+
+func (r classifiedRequest) toolLabel() string {
+	if r.kind == unknownTool {
+		return unknownLabel
+	}
+	return r.tool
+}
+
+func (r classifiedRequest) skillLabel() string {
+	if !r.known {
+		return unknownLabel
+	}
+	return r.skill
+}
+
+func fetchResultSummary(result *mcp.CallToolResult, err error) (string, int) {
+	if err != nil || result == nil {
+		return "error", 0
+	}
+	payload, ok := result.StructuredContent.(map[string]any)
+	if !ok {
+		return "error", 0
+	}
+	if code, ok := payload["error_code"].(string); ok {
+		return code, 0
+	}
+	returned, _ := payload["returned_bytes"].(int)
+	return "ok", returned
+}
+
+func resourceContentBytes(contents []mcp.ResourceContents) int {
+	total := 0
+	for _, content := range contents {
+		switch value := content.(type) {
+		case mcp.TextResourceContents:
+			total += len(value.Text)
+		case mcp.BlobResourceContents:
+			decoded, err := base64.StdEncoding.DecodeString(value.Blob)
+			if err == nil {
+				total += len(decoded)
+			}
+		}
+	}
+	return total
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Render(w)
+}
+
+// Render writes all metrics in the Prometheus text format.
+func (m *Metrics) Render(w io.Writer) {
+	m.mu.Lock()
+	writeCounter(w, "skillz_tool_calls_total", "Tool invocations by tool name and outcome.", m.toolCalls)
+	writeHistogram(w, "skillz_tool_call_duration_seconds", "Tool invocation latency in seconds.", m.toolDurations)
+	writeCounter(w, "skillz_fetch_resource_total", "fetch_resource calls by outcome or error code.", m.fetches)
+	writeCounter(w, "skillz_resource_reads_total", "MCP resource reads by skill and outcome.", m.reads)
+	writeCounter(w, "skillz_resource_bytes_served_total", "Resource bytes returned to clients by skill and method.", m.bytesServed)
+	m.mu.Unlock()
+
+	if m.registry == nil {
+		return
+	}
+	status := m.registry.Status()
+	writeGauge(w, "skillz_registry_load_duration_seconds", "Duration of the last successful registry load.", map[string]float64{"": status.LoadDuration.Seconds()})
+	bySource := map[string]float64{}
+	for _, skill := range m.registry.Skills() {
+		bySource[labels("source", skillSourceType(skill))]++
+	}
+	writeGauge(w, "skillz_registry_skills", "Loaded skills by source type.", bySource)
+}
+
+func skillSourceType(skill Skill) string {
+	if isTarArchiveName(skill.ZipPath) {
+		return "tar"
+	}
+	if skill.IsZip() {
+		return "zip"
+	}
+	return "dir"
+}
+
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], value))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func sortedSeries[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeCounter(w io.Writer, name string, help string, series map[string]float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, key := range sortedSeries(series) {
+		fmt.Fprintf(w, "%s%s %s\n", name, key, formatFloat(series[key]))
+	}
+}
+
+func writeGauge(w io.Writer, name string, help string, series map[string]float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, key := range sortedSeries(series) {
+		fmt.Fprintf(w, "%s%s %s\n", name, key, formatFloat(series[key]))
+	}
+}
+
+func writeHistogram(w io.Writer, name string, help string, series map[string]*histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, key := range sortedSeries(series) {
+		observed := series[key]
+		prefix := strings.TrimSuffix(strings.TrimPrefix(key, "{"), "}")
+		if prefix != "" {
+			prefix += ","
+		}
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatFloat(bound), observed.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, observed.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, key, formatFloat(observed.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, key, observed.count)
+	}
+}
+
+func formatFloat(value float64) string {
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%g", value)
+}
+
+// ServeMetrics exposes metrics on a separate admin address until ctx is
+// cancelled.
+func ServeMetrics(ctx context.Context, address string, metrics *Metrics) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	httpServer := &http.Server{Addr: address, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package skillz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMetricsRecordToolCallsFetchesAndReads(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	metrics := NewMetrics(registry)
+	mcpServer := BuildMCPServer(registry, ServerOptions{Metrics: metrics})
+
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "testskill",
+		"arguments": map[string]any{"task": "go"},
+	})
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/script.py"},
+	})
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/missing/file.txt"},
+	})
+	callServer(t, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/data.bin"})
+
+	var output strings.Builder
+	metrics.Render(&output)
+	rendered := output.String()
+	for _, expected := range []string{
+		`skillz_tool_calls_total{tool="testskill",outcome="ok"} 1`,
+		`skillz_tool_call_duration_seconds_count{tool="fetch_resource"} 2`,
+		`skillz_fetch_resource_total{outcome="ok"} 1`,
+		`skillz_fetch_resource_total{outcome="skill_not_found"} 1`,
+		`skillz_resource_reads_total{skill="testskill",outcome="ok"} 1`,
+		`skillz_resource_bytes_served_total{skill="testskill",method="fetch_resource"} 14`,
+		`skillz_resource_bytes_served_total{skill="testskill",method="resources_read"} 6`,
+		`skillz_registry_skills{source="dir"} 1`,
+		`# TYPE skillz_registry_load_duration_seconds gauge`,
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Fatalf("missing %q in:\n%s", expected, rendered)
+		}
+	}
+}
+
+func TestMetricsCollapseUnknownLabels(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	metrics := NewMetrics(registry)
+	mcpServer := BuildMCPServer(registry, ServerOptions{Metrics: metrics})
+	mcpServer.AddTool(mcp.NewTool("host_tool"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	callServer(t, mcpServer, "tools/call", map[string]any{"name": "host_tool", "arguments": map[string]any{}})
+	for _, slug := range []string{"made-up-1", "made-up-2"} {
+		request := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"resource://skillz/` + slug + `/file.txt"}}`
+		mcpServer.HandleMessage(context.Background(), []byte(request))
+	}
+
+	var output strings.Builder
+	metrics.Render(&output)
+	rendered := output.String()
+	if strings.Contains(rendered, "made-up") || strings.Contains(rendered, "host_tool") {
+		t.Fatalf("expected unknown labels to be collapsed:\n%s", rendered)
+	}
+	for _, expected := range []string{
+		`skillz_tool_calls_total{tool="unknown",outcome="ok"} 1`,
+		`skillz_resource_reads_total{skill="unknown",outcome="error"} 2`,
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Fatalf("missing %q in:\n%s", expected, rendered)
+		}
+	}
+}
+
+func TestLabelsEscapeValues(t *testing.T) {
+	if got := labels("skill", "a\"b\\c"); got != `{skill="a\"b\\c"}` {
+		t.Fatalf("unexpected labels: %s", got)
+	}
+}
@@ -0,0 +1,140 @@
+package skillz
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Middleware injects logic around skill invocations, resource reads and
+// registry loads. Every hook is optional. A hook that returns an error
+// rejects the event: the tool call or read fails with that error, or Load
+// returns it. In a chain, Before hooks run in order and After hooks in reverse
+// order, so the first middleware wraps all the others.
+type Middleware struct {
+	// BeforeSkillCall runs once the skill is resolved, before its response is
+	// built.
+	BeforeSkillCall func(ctx context.Context, call *SkillCall) error
+	// AfterSkillCall may modify call.Response before it is returned.
+	AfterSkillCall func(ctx context.Context, call *SkillCall) error
+	// BeforeResourceRead runs before the resource is read.
+	BeforeResourceRead func(ctx context.Context, read *ResourceRead) error
+	// AfterResourceRead may replace read.Data, e.g. to redact it, before it
+	// is returned.
+	AfterResourceRead func(ctx context.Context, read *ResourceRead) error
+	// BeforeLoad runs before the registry scans its sources.
+	BeforeLoad func(ctx context.Context, load *RegistryLoad) error
+	// AfterLoad may remove skills from load.Skills or modify them; the
+	// registry keeps the result.
+	AfterLoad func(ctx context.Context, load *RegistryLoad) error
+}
+
+// Caller describes who made a request.
+type Caller struct {
+	// Identity is the authenticated caller, or the anonymous identity.
+	Identity Identity
+	// Client is the name/version the client reported when it initialized.
+	Client string
+	// Transport is stdio, http or sse; empty outside RunMCPServer.
+	Transport string
+}
+
+// CallerFromContext describes the caller of the request handled under ctx.
+func CallerFromContext(ctx context.Context) Caller {
+	return Caller{Identity: callerIdentity(ctx), Client: clientName(ctx), Transport: transportFromContext(ctx)}
+}
+
+// SkillCall is an invocation of a skill tool.
+type SkillCall struct {
+	Skill   Skill
+	Request mcp.CallToolRequest
+	Caller  Caller
+	// Response is the structured result returned to the client. It is nil
+	// for Before hooks.
+	Response map[string]any
+}
+
+// ResourceRead is a read of one skill resource through resources/read,
+// fetch_resource or search_resources.
+type ResourceRead struct {
+	Skill Skill
+	// Path is the resource path within the skill.
+	Path string
+	URI  string
+	// Method is "resources/read", "fetch_resource" or "search_resources".
+	Method   string
+	Caller   Caller
+	MIMEType string
+	// Data is the content being returned, possibly a range of the resource.
+	// It is nil for Before hooks.
+	Data []byte
+}
+
+// RegistryLoad is a Load of a Registry.
+type RegistryLoad struct {
+	Registry *Registry
+	// Skills holds the discovered skills, sorted by slug. It is nil for
+	// Before hooks.
+	Skills []Skill
+}
+
+func runBefore[E any](ctx context.Context, chain []Middleware, hook func(Middleware) func(context.Context, *E) error, event *E) error {
+	for _, middleware := range chain {
+		if before := hook(middleware); before != nil {
+			if err := before(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func runAfter[E any](ctx context.Context, chain []Middleware, hook func(Middleware) func(context.Context, *E) error, event *E) error {
+	for index := len(chain) - 1; index >= 0; index-- {
+		if after := hook(chain[index]); after != nil {
+			if err := after(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func beforeSkillCall(m Middleware) func(context.Context, *SkillCall) error { return m.BeforeSkillCall }
+func afterSkillCall(m Middleware) func(context.Context, *SkillCall) error  { return m.AfterSkillCall }
+func beforeResourceRead(m Middleware) func(context.Context, *ResourceRead) error {
+	return m.BeforeResourceRead
+}
+func afterResourceRead(m Middleware) func(context.Context, *ResourceRead) error {
+	return m.AfterResourceRead
+}
+func beforeLoad(m Middleware) func(context.Context, *RegistryLoad) error { return m.BeforeLoad }
+func afterLoad(m Middleware) func(context.Context, *RegistryLoad) error  { return m.AfterLoad }
+
+// resourceInterceptor runs a middleware chain around the read of one
+// resource on behalf of a request.
+type resourceInterceptor struct {
+	ctx    context.Context
+	chain  []Middleware
+	method string
+}
+
+func (i *resourceInterceptor) before(skill Skill, relPath string, uri string) (*ResourceRead, error) {
+	read := &ResourceRead{
+		Skill:    skill,
+		Path:     relPath,
+		URI:      uri,
+		Method:   i.method,
+		Caller:   CallerFromContext(i.ctx),
+		MIMEType: toOptionalString(detectMimeType(relPath)),
+	}
+	return read, runBefore(i.ctx, i.chain, beforeResourceRead, read)
+}
+
+func (i *resourceInterceptor) after(read *ResourceRead, data []byte) ([]byte, error) {
+	read.Data = data
+	if err := runAfter(i.ctx, i.chain, afterResourceRead, read); err != nil {
+		return nil, err
+	}
+	return read.Data, nil
+}
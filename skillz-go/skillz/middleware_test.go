@@ -0,0 +1,172 @@
+package skillz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRedactsResourceReads(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	methods := []string{}
+	redact := Middleware{
+		BeforeResourceRead: func(ctx context.Context, read *ResourceRead) error {
+			if read.Path == "data.bin" {
+				return errors.New("binary resources are blocked")
+			}
+			return nil
+		},
+		AfterResourceRead: func(ctx context.Context, read *ResourceRead) error {
+			methods = append(methods, read.Method)
+			read.Data = []byte(strings.ReplaceAll(string(read.Data), "hello", "[redacted]"))
+			return nil
+		},
+	}
+	mcpServer := NewServer(registry, WithMiddleware(redact))
+
+	result := callServer(t, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/script.py"})
+	if text := result["contents"].([]any)[0].(map[string]any)["text"]; text != "print('[redacted]')" {
+		t.Fatalf("expected redacted resource read, got %v", text)
+	}
+	called := callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/script.py"},
+	})
+	fetched := called["structuredContent"].(map[string]any)
+	if content := fetched["content"]; content != "print('[redacted]')" {
+		t.Fatalf("expected redacted fetch, got %v", content)
+	}
+	// Paging follows the bytes read, not the redacted bytes returned.
+	if fetched["length"] != float64(len("print('hello')")) || fetched["returned_bytes"] != float64(len("print('[redacted]')")) {
+		t.Fatalf("unexpected lengths: length=%v returned_bytes=%v", fetched["length"], fetched["returned_bytes"])
+	}
+	called = callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/data.bin"},
+	})
+	if code := called["structuredContent"].(map[string]any)["error_code"]; code != errorCodeRejected {
+		t.Fatalf("expected rejected fetch, got %v", called)
+	}
+	if strings.Join(methods, ",") != "resources/read,fetch_resource" {
+		t.Fatalf("unexpected methods: %v", methods)
+	}
+}
+
+func TestMiddlewareRedactsSearchResults(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	redact := Middleware{
+		AfterResourceRead: func(ctx context.Context, read *ResourceRead) error {
+			if read.Method != "search_resources" {
+				t.Errorf("unexpected method %q", read.Method)
+			}
+			read.Data = []byte(strings.ReplaceAll(string(read.Data), "hello", "[redacted]"))
+			return nil
+		},
+	}
+	mcpServer := NewServer(registry, WithMiddleware(redact))
+
+	search := func(pattern string) []any {
+		called := callServer(t, mcpServer, "tools/call", map[string]any{
+			"name":      "search_resources",
+			"arguments": map[string]any{"pattern": pattern},
+		})
+		return called["structuredContent"].(map[string]any)["matches"].([]any)
+	}
+	if matches := search("hello"); len(matches) != 0 {
+		t.Fatalf("expected the redacted secret not to match, got %v", matches)
+	}
+	matches := search("print")
+	if len(matches) != 1 || matches[0].(map[string]any)["text"] != "print('[redacted]')" {
+		t.Fatalf("expected a redacted match, got %v", matches)
+	}
+}
+
+func TestMiddlewareWrapsSkillCalls(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	writeSkill(t, temp, "quota")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	order := []string{}
+	outer := Middleware{
+		BeforeSkillCall: func(ctx context.Context, call *SkillCall) error {
+			order = append(order, "outer-before")
+			if call.Skill.Slug == "quota" {
+				return errors.New("quota exceeded")
+			}
+			return nil
+		},
+		AfterSkillCall: func(ctx context.Context, call *SkillCall) error {
+			order = append(order, "outer-after")
+			return nil
+		},
+	}
+	inner := Middleware{
+		AfterSkillCall: func(ctx context.Context, call *SkillCall) error {
+			order = append(order, "inner-after")
+			call.Response["instructions"] = call.Response["instructions"].(string) + "Always answer in French.\n"
+			return nil
+		},
+	}
+	mcpServer := NewServer(registry, WithMiddleware(outer, inner))
+
+	called := callServer(t, mcpServer, "tools/call", map[string]any{"name": "echo", "arguments": map[string]any{"task": "hi"}})
+	instructions := called["structuredContent"].(map[string]any)["instructions"]
+	if instructions != "Body\nAlways answer in French.\n" {
+		t.Fatalf("expected augmented instructions, got %q", instructions)
+	}
+	if strings.Join(order, ",") != "outer-before,inner-after,outer-after" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	message := []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"quota","arguments":{"task":"hi"}}}`)
+	encoded, _ := json.Marshal(mcpServer.HandleMessage(context.Background(), message))
+	if !strings.Contains(string(encoded), "quota exceeded") {
+		t.Fatalf("expected rejected call, got %s", encoded)
+	}
+}
+
+func TestMiddlewareFiltersAndRejectsLoads(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	writeSkill(t, temp, "draft")
+	registry := NewRegistry(temp)
+	registry.Middleware = []Middleware{{
+		AfterLoad: func(ctx context.Context, load *RegistryLoad) error {
+			kept := load.Skills[:0]
+			for _, skill := range load.Skills {
+				if skill.Slug != "draft" {
+					kept = append(kept, skill)
+				}
+			}
+			load.Skills = kept
+			return nil
+		},
+	}}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := registry.Get("draft"); err == nil || len(registry.Skills()) != 1 {
+		t.Fatalf("expected draft to be dropped, got %v", registry.Skills())
+	}
+
+	registry.Middleware = []Middleware{{
+		BeforeLoad: func(ctx context.Context, load *RegistryLoad) error { return errors.New("maintenance window") },
+	}}
+	if err := registry.Load(); err == nil || registry.Status().Loaded {
+		t.Fatalf("expected rejected load, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package skillz
+
+import (
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Exposure selects the MCP surfaces that skills are published on. Values
+// combine with |; the zero value means ExposeAll.
+type Exposure uint8
+
+const (
+	// ExposeSkillTools registers one tool per skill that returns its
+	// instructions.
+	ExposeSkillTools Exposure = 1 << iota
+	// ExposeHelperTools registers fetch_resource, search_resources and
+	// list_skill_files.
+	ExposeHelperTools
+	// ExposeResources registers skill files as MCP resources, or as a
+	// resource template for lazy registries.
+	ExposeResources
+
+	ExposeAll = ExposeSkillTools | ExposeHelperTools | ExposeResources
+)
+
+func (e Exposure) has(surface Exposure) bool {
+	return e == 0 || e&surface != 0
+}
+
+// Option configures the server built by NewServer or mounted by Mount.
+type Option func(*ServerOptions)
+
+// NewServerOptions applies opts to the zero ServerOptions.
+func NewServerOptions(opts ...Option) ServerOptions {
+	options := ServerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithMaxPayloadBytes caps the content returned by fetch_resource and
+// resource reads.
+func WithMaxPayloadBytes(limit int64) Option {
+	return func(o *ServerOptions) { o.MaxPayloadBytes = limit }
+}
+
+// WithPolicy restricts which skills each caller can list, invoke and read.
+func WithPolicy(policy *Policy) Option {
+	return func(o *ServerOptions) { o.Policy = policy }
+}
+
+// WithFilter limits the server to the skills filter returns true for.
+func WithFilter(filter func(Skill) bool) Option {
+	return func(o *ServerOptions) { o.Filter = filter }
+}
+
+// WithExposure selects the MCP surfaces skills are published on.
+func WithExposure(exposure Exposure) Option {
+	return func(o *ServerOptions) { o.Exposure = exposure }
+}
+
+// WithHooks makes skillz add its hooks to hooks rather than installing its
+// own, so a host server keeps the hooks it registers there.
+func WithHooks(hooks *server.Hooks) Option {
+	return func(o *ServerOptions) { o.Hooks = hooks }
+}
+
+// WithMiddleware appends middleware to the chain run around skill
+// invocations and resource reads.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *ServerOptions) { o.Middleware = append(o.Middleware, middleware...) }
+}
+
+// WithMetrics records tool calls and resource reads in metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *ServerOptions) { o.Metrics = metrics }
+}
+
+// WithUsageLog appends every tool call and resource read to usage.
+func WithUsageLog(usage *UsageLog) Option {
+	return func(o *ServerOptions) { o.Usage = usage }
+}
+
+// WithAuditLog records every disclosed resource in audit.
+func WithAuditLog(audit *AuditLog) Option {
+	return func(o *ServerOptions) { o.Audit = audit }
+}
+
+// WithTracer records a span for each tool call and resource read.
+func WithTracer(tracer *Tracer) Option {
+	return func(o *ServerOptions) { o.Tracer = tracer }
+}
+
+// WithLogger logs one line per tool call and resource read.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *ServerOptions) { o.Logger = logger }
+}
+
+// NewServer creates a standalone MCP server publishing the skills of registry.
+func NewServer(registry *Registry, opts ...Option) *server.MCPServer {
+	return BuildMCPServer(registry, NewServerOptions(opts...))
+}
+
+// ServerMiddleware returns the server options a host passes to
+// server.NewMCPServer before mounting skills with the same opts: hooks that
+// apply the policy and filter to listings, and the middleware that traces,
+// meters, audits and logs requests. Hosts with hooks of their own pass them
+// with WithHooks, since a server keeps only one set of hooks.
+func ServerMiddleware(registry *Registry, opts ...Option) []server.ServerOption {
+	return NewServerOptions(opts...).middleware(registry)
+}
+
+// Mount registers the skillz tools and resources of registry on an existing
+// server. The server should enable tool capabilities, and resource
+// capabilities unless resources are not exposed.
+func Mount(mcpServer *server.MCPServer, registry *Registry, opts ...Option) {
+	mount(mcpServer, registry, NewServerOptions(opts...))
+}
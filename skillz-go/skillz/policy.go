@@ -0,0 +1,140 @@
+package skillz
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// anonymousSubject is the identity used for callers that did not
+// authenticate, such as the stdio transport.
+const anonymousSubject = "anonymous"
+
+// Policy decides which skills a caller may see. Rules apply to a caller when
+// its subject or one of its groups is listed (or when "*" is listed). A skill
+// matched by a deny pattern of any applicable rule is hidden; otherwise it is
+// visible when an allow pattern matches, and falls back to Default.
+//
+// Patterns are globs over skill slugs, or "tag:<name>" to match the tags
+// declared in the skill's front matter.
+type Policy struct {
+	Default string       `yaml:"default"`
+	Rules   []PolicyRule `yaml:"rules"`
+}
+
+type PolicyRule struct {
+	Subjects []string `yaml:"subjects"`
+	Groups   []string `yaml:"groups"`
+	Allow    []string `yaml:"allow"`
+	Deny     []string `yaml:"deny"`
+}
+
+func LoadPolicy(policyPath string) (*Policy, error) {
+	raw, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(raw, policy); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", policyPath, err)
+	}
+	policy.Default = strings.ToLower(strings.TrimSpace(policy.Default))
+	if policy.Default == "" {
+		policy.Default = "deny"
+	}
+	if policy.Default != "allow" && policy.Default != "deny" {
+		return nil, fmt.Errorf("policy %s: default must be 'allow' or 'deny'", policyPath)
+	}
+	for _, rule := range policy.Rules {
+		for _, pattern := range append(append([]string{}, rule.Allow...), rule.Deny...) {
+			if _, err := path.Match(strings.TrimPrefix(pattern, "tag:"), ""); err != nil {
+				return nil, fmt.Errorf("policy %s: invalid pattern %q", policyPath, pattern)
+			}
+		}
+	}
+	return policy, nil
+}
+
+func (p *Policy) Allows(identity Identity, skill Skill) bool {
+	if p == nil {
+		return true
+	}
+	allowed := false
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(identity) {
+			continue
+		}
+		if matchesAnyPattern(rule.Deny, skill) {
+			return false
+		}
+		if matchesAnyPattern(rule.Allow, skill) {
+			allowed = true
+		}
+	}
+	return allowed || p.Default == "allow"
+}
+
+func (r PolicyRule) appliesTo(identity Identity) bool {
+	for _, subject := range r.Subjects {
+		if subject == "*" || subject == identity.Subject {
+			return true
+		}
+	}
+	for _, group := range r.Groups {
+		for _, member := range identity.Groups {
+			if group == member {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAnyPattern(patterns []string, skill Skill) bool {
+	for _, pattern := range patterns {
+		if tag, ok := strings.CutPrefix(pattern, "tag:"); ok {
+			for _, skillTag := range skillTags(skill) {
+				if matched, _ := path.Match(tag, skillTag); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, skill.Slug); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// skillTags reads the "tags" front matter key, given either as a list or as a
+// comma-separated string.
+func skillTags(skill Skill) []string {
+	tags := []string{}
+	switch value := skill.Metadata.Extra["tags"].(type) {
+	case string:
+		for _, part := range strings.Split(value, ",") {
+			if trimmed := strings.TrimSpace(part); trimmed != "" {
+				tags = append(tags, trimmed)
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if trimmed := strings.TrimSpace(toString(item)); trimmed != "" {
+				tags = append(tags, trimmed)
+			}
+		}
+	}
+	return tags
+}
+
+func callerIdentity(ctx context.Context) Identity {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		return identity
+	}
+	return Identity{Subject: anonymousSubject}
+}
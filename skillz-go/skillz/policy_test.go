@@ -0,0 +1,130 @@
+package skillz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func writePolicy(t *testing.T, content string) *Policy {
+	t.Helper()
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	policy, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	return policy
+}
+
+const testPolicy = `
+default: deny
+rules:
+  - subjects: ["*"]
+    allow: ["tag:public"]
+  - groups: [staff]
+    allow: ["deploy-*"]
+  - subjects: [contractor]
+    deny: ["deploy-*"]
+`
+
+func TestPolicyAllowsBySubjectGroupAndTag(t *testing.T) {
+	policy := writePolicy(t, testPolicy)
+	public := Skill{Slug: "docs", Metadata: SkillMetadata{Extra: map[string]any{"tags": []any{"public"}}}}
+	deploy := Skill{Slug: "deploy-prod"}
+
+	staff := Identity{Subject: "alice", Groups: []string{"staff"}}
+	if !policy.Allows(staff, public) || !policy.Allows(staff, deploy) {
+		t.Fatalf("expected staff to see both skills")
+	}
+	contractor := Identity{Subject: "contractor", Groups: []string{"staff"}}
+	if policy.Allows(contractor, deploy) {
+		t.Fatalf("expected deny rule to win for contractor")
+	}
+	anonymous := Identity{Subject: anonymousSubject}
+	if !policy.Allows(anonymous, public) || policy.Allows(anonymous, deploy) {
+		t.Fatalf("unexpected anonymous access")
+	}
+}
+
+func TestPolicyEnforcedByServer(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "docs", withTags("public"), withFile("notes.txt", "notes"))
+	writeSkill(t, temp, "deploy-prod", withTags("internal"), withFile("notes.txt", "notes"))
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{Policy: writePolicy(t, testPolicy)})
+	contractor := withIdentity(context.Background(), Identity{Subject: "contractor"})
+
+	listed := callServerWithContext(t, contractor, mcpServer, "tools/list", map[string]any{})
+	names := []string{}
+	for _, tool := range listed["tools"].([]any) {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if strings.Contains(strings.Join(names, ","), "deploy-prod") {
+		t.Fatalf("deploy-prod should be hidden, got %v", names)
+	}
+
+	resources := callServerWithContext(t, contractor, mcpServer, "resources/list", map[string]any{})
+	for _, resource := range resources["resources"].([]any) {
+		if strings.Contains(resource.(map[string]any)["uri"].(string), "deploy-prod") {
+			t.Fatalf("deploy-prod resources should be hidden")
+		}
+	}
+
+	fetched := callServerWithContext(t, contractor, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/deploy-prod/notes.txt"},
+	})
+	content := fetched["structuredContent"].(map[string]any)["content"].(string)
+	if !strings.HasPrefix(content, "Error: skill not found") {
+		t.Fatalf("expected hidden skill to be reported as not found, got %q", content)
+	}
+
+	staff := withIdentity(context.Background(), Identity{Subject: "alice", Groups: []string{"staff"}})
+	fetched = callServerWithContext(t, staff, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/deploy-prod/notes.txt"},
+	})
+	if fetched["structuredContent"].(map[string]any)["content"] != "notes" {
+		t.Fatalf("expected staff to read deploy-prod resource")
+	}
+}
+
+func TestPolicyKeepsHostToolsNamedLikeSkills(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "docs", withTags("public"), withFile("notes.txt", "notes"))
+	writeSkill(t, temp, "deploy-prod", withTags("internal"), withFile("notes.txt", "notes"))
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	options := []Option{WithPolicy(writePolicy(t, testPolicy)), WithExposure(ExposeHelperTools)}
+	host := server.NewMCPServer("host", "1.0.0", append(ServerMiddleware(registry, options...), server.WithToolCapabilities(true))...)
+	host.AddTool(mcp.NewTool("deploy-prod"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("deployed"), nil
+	})
+	Mount(host, registry, options...)
+
+	contractor := withIdentity(context.Background(), Identity{Subject: "contractor"})
+	listed := callServerWithContext(t, contractor, host, "tools/list", map[string]any{})
+	names := []string{}
+	for _, tool := range listed["tools"].([]any) {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if !slices.Contains(names, "deploy-prod") {
+		t.Fatalf("expected the host tool to stay listed, got %v", names)
+	}
+}
@@ -0,0 +1,665 @@
+package skillz
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registry discovers skills and serves them to concurrent readers. Its
+// configuration fields must be set before the first Load; after that Load,
+// Get, Resolve and Skills are safe to call from any goroutine.
+type Registry struct {
+	Root string
+	// Lazy defers reading SKILL.md bodies and enumerating resources until a
+	// skill is first resolved; only front matter is parsed by Load.
+	Lazy bool
+	// Logger receives discovery diagnostics such as skipped skills. Nil
+	// discards them.
+	Logger *slog.Logger
+	// Tracer, when set, records a span for each Load with child spans per
+	// scanned directory and archive.
+	Tracer *Tracer
+	// Sources are scanned after Root, in order. Skills found earlier win, so
+	// local skills shadow those of later sources.
+	Sources []Source
+	// Concurrency bounds how many directories Load scans and how many
+	// candidates it parses at once. Zero means four per GOMAXPROCS, since
+	// both mostly wait on disk.
+	Concurrency int
+	// Middleware runs around each Load; see Middleware.BeforeLoad and
+	// Middleware.AfterLoad.
+	Middleware []Middleware
+	// IndexPath, when set, names a file caching parsed front matter and
+	// resource lists between runs. Load reuses the entries of candidates
+	// whose size and modification time are unchanged and rewrites the file
+	// when anything changed, so a restart parses only what was edited.
+	IndexPath string
+
+	// snapshot holds the skills of the last successful Load. Loads build a
+	// new snapshot and swap it in, so readers never see a partial reload.
+	snapshot atomic.Pointer[registrySnapshot]
+	// loadMu serialises Loads.
+	loadMu sync.Mutex
+	// mu guards the load status and the lazily loaded content.
+	mu           sync.Mutex
+	content      map[string]Skill
+	loadedAt     time.Time
+	loadDuration time.Duration
+	loadErr      error
+}
+
+// registrySnapshot is an immutable set of skills indexed by slug and name.
+type registrySnapshot struct {
+	bySlug map[string]Skill
+	byName map[string]Skill
+	// skills is sorted by slug.
+	skills []Skill
+}
+
+func newRegistrySnapshot() *registrySnapshot {
+	return &registrySnapshot{bySlug: map[string]Skill{}, byName: map[string]Skill{}}
+}
+
+func (s *registrySnapshot) add(skill Skill) {
+	s.bySlug[skill.Slug] = skill
+	s.byName[skill.Metadata.Name] = skill
+}
+
+// seal sorts the skills; the snapshot must not be modified afterwards.
+func (s *registrySnapshot) seal() *registrySnapshot {
+	s.skills = make([]Skill, 0, len(s.bySlug))
+	for _, skill := range s.bySlug {
+		s.skills = append(s.skills, skill)
+	}
+	sort.Slice(s.skills, func(i, j int) bool {
+		return s.skills[i].Slug < s.skills[j].Slug
+	})
+	return s
+}
+
+type RegistryStatus struct {
+	Loaded       bool          `json:"loaded"`
+	LoadedAt     time.Time     `json:"loaded_at"`
+	LoadDuration time.Duration `json:"load_duration_ns"`
+	Skills       int           `json:"skills"`
+	Error        string        `json:"error,omitempty"`
+}
+
+func NewRegistry(root string) *Registry {
+	return &Registry{Root: root}
+}
+
+// current returns the snapshot of the last successful Load.
+func (r *Registry) current() *registrySnapshot {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot
+	}
+	return emptySnapshot
+}
+
+var emptySnapshot = newRegistrySnapshot().seal()
+
+// Skills lists the skills of the last successful Load, sorted by slug. It is
+// safe to call concurrently with Load.
+func (r *Registry) Skills() []Skill {
+	return append([]Skill(nil), r.current().skills...)
+}
+
+func (r *Registry) Get(slug string) (Skill, error) {
+	skill, ok := r.current().bySlug[slug]
+	if !ok {
+		return Skill{}, SkillError{Code: "skill_error", Message: fmt.Sprintf("unknown skill '%s'", slug)}
+	}
+	return skill, nil
+}
+
+// Resolve returns the skill with its instructions and resources loaded. In lazy
+// mode the content is read on first use and reloaded whenever SKILL.md, the
+// archive or a directory holding resources is modified.
+func (r *Registry) Resolve(slug string) (Skill, error) {
+	skill, err := r.Get(slug)
+	if err != nil || !r.Lazy {
+		return skill, err
+	}
+
+	modTime, err := skillModTime(skill)
+	if err != nil {
+		return Skill{}, SkillError{Code: "skill_error", Message: fmt.Sprintf("skill '%s' is no longer available", slug)}
+	}
+	key := skill.Origin + "\x00" + skill.candidate
+	r.mu.Lock()
+	cached, ok := r.content[key]
+	r.mu.Unlock()
+	if ok && modTime.Equal(cached.modTime) && directoriesUnchanged(skill.src, skill.candidate, cached.directories) {
+		return cached, nil
+	}
+
+	started := time.Now()
+	if err := loadSkillContent(&skill); err != nil {
+		return Skill{}, SkillError{Code: "skill_error", Message: fmt.Sprintf("failed to load skill '%s': %v", slug, err)}
+	}
+	skill.modTime = modTime
+	skill.ContentLoadTime = time.Since(started)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.content == nil {
+		r.content = map[string]Skill{}
+	}
+	r.content[key] = skill
+	return skill, nil
+}
+
+// Status reports whether the last Load succeeded and how many skills it found.
+func (r *Registry) Status() RegistryStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := RegistryStatus{
+		Loaded:       !r.loadedAt.IsZero() && r.loadErr == nil,
+		LoadedAt:     r.loadedAt,
+		LoadDuration: r.loadDuration,
+		Skills:       len(r.current().skills),
+	}
+	if r.loadErr != nil {
+		status.Error = r.loadErr.Error()
+	}
+	return status
+}
+
+// Load discovers skills and replaces the registry's skills with them. The
+// previous skills stay visible to concurrent readers until the new set is
+// complete, and remain in place if Load fails.
+func (r *Registry) Load() error {
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+	ctx, span := r.Tracer.Start(context.Background(), "skillz.registry.load")
+	defer span.End()
+	span.SetAttribute("skillz.root", r.Root)
+	span.SetAttribute("skillz.lazy", r.Lazy)
+
+	started := time.Now()
+	event := &RegistryLoad{Registry: r}
+	var snapshot *registrySnapshot
+	err := runBefore(ctx, r.Middleware, beforeLoad, event)
+	if err == nil {
+		snapshot, err = r.load(ctx)
+	}
+	if err == nil && len(r.Middleware) > 0 {
+		event.Skills = append([]Skill(nil), snapshot.skills...)
+		if err = runAfter(ctx, r.Middleware, afterLoad, event); err == nil {
+			snapshot = newRegistrySnapshot()
+			for _, skill := range event.Skills {
+				snapshot.add(skill)
+			}
+			snapshot.seal()
+		}
+	}
+	span.SetError(err)
+
+	if err := r.storeSnapshot(snapshot, err, started); err != nil {
+		return err
+	}
+	span.SetAttribute("skillz.skills", len(snapshot.skills))
+	r.pruneSources()
+	return nil
+}
+
+// storeSnapshot swaps in the snapshot of a successful load, or records err.
+func (r *Registry) storeSnapshot(snapshot *registrySnapshot, err error, started time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadErr = err
+	if err != nil {
+		r.logger().Error("failed to load skills", slog.String("root", r.Root), slog.String("error", err.Error()))
+		return err
+	}
+	r.snapshot.Store(snapshot)
+	r.content = nil
+	r.loadedAt = time.Now()
+	r.loadDuration = time.Since(started)
+	r.logger().Info("skills loaded",
+		slog.String("root", r.Root),
+		slog.Int("skills", len(snapshot.skills)),
+		slog.Duration("duration", r.loadDuration),
+	)
+	return nil
+}
+
+func (r *Registry) logger() *slog.Logger {
+	if r.Logger == nil {
+		return discardLogger()
+	}
+	return r.Logger
+}
+
+// skipSkill records why a candidate skill was not registered.
+func (r *Registry) skipSkill(candidate string, reason string, err error) {
+	attributes := []slog.Attr{slog.String("source", candidate), slog.String("reason", reason)}
+	if err != nil {
+		attributes = append(attributes, slog.String("error", err.Error()))
+	}
+	r.logger().LogAttrs(context.Background(), slog.LevelWarn, "skipping skill", attributes...)
+}
+
+// load discovers the skills of every source into a new snapshot.
+func (r *Registry) load(ctx context.Context) (*registrySnapshot, error) {
+	ctx = withScanConcurrency(ctx, r.concurrency())
+	sources := make([]Source, 0, len(r.Sources)+1)
+	if r.Root != "" || len(r.Sources) == 0 {
+		stat, err := os.Stat(r.Root)
+		if err != nil || !stat.IsDir() {
+			return nil, SkillError{Code: "skill_error", Message: fmt.Sprintf("skills root %s does not exist or is not a directory", r.Root)}
+		}
+		root, err := NewDirSource(localOrigin, r.Root)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, root)
+	}
+	sources = append(sources, r.Sources...)
+
+	discovered := []*discoveredSkill{}
+	for index, source := range sources {
+		candidates, err := source.Candidates(ctx)
+		if err != nil {
+			if index == 0 && r.Root != "" {
+				return nil, err
+			}
+			if errors.Is(err, fs.ErrNotExist) {
+				r.logger().Debug("source not synced yet", slog.String("source", source.Name()))
+				continue
+			}
+			r.logger().Warn("skipping source", slog.String("source", source.Name()), slog.String("error", err.Error()))
+			continue
+		}
+		for _, candidate := range candidates {
+			discovered = append(discovered, &discoveredSkill{source: source, candidate: candidate})
+		}
+	}
+
+	index := r.readDiscoveryIndex()
+	r.parseCandidates(ctx, discovered, index)
+
+	// Claims are made in discovery order, so the outcome matches a serial
+	// scan however the parsing was scheduled.
+	snapshot := newRegistrySnapshot()
+	for _, found := range discovered {
+		r.claim(snapshot, found)
+	}
+	if index != nil {
+		r.updateDiscoveryIndex(index, discovered)
+	}
+	return snapshot.seal(), nil
+}
+
+// updateDiscoveryIndex rewrites the index with the entries of this Load,
+// dropping those of candidates that no longer exist. A failure to write it
+// only costs the next Load its head start.
+func (r *Registry) updateDiscoveryIndex(index *discoveryIndex, discovered []*discoveredSkill) {
+	updated := newDiscoveryIndex()
+	changed := false
+	for _, found := range discovered {
+		if found.entry == nil {
+			continue
+		}
+		updated.Entries[indexKey(found.source, found.candidate)] = found.entry
+		changed = changed || !found.indexed
+	}
+	if !changed && len(updated.Entries) == len(index.Entries) {
+		return
+	}
+	if err := r.writeDiscoveryIndex(updated); err != nil {
+		r.logger().Warn("failed to write discovery index", slog.String("path", r.IndexPath), slog.String("error", err.Error()))
+	}
+}
+
+// discoveredSkill is a candidate and the outcome of parsing it.
+type discoveredSkill struct {
+	source    Source
+	candidate string
+
+	skill Skill
+	// skipReason and err explain why the candidate is not a valid skill.
+	skipReason string
+	err        error
+	// resourcesErr reports a failure to enumerate the resources of an
+	// otherwise valid skill.
+	resourcesErr error
+	// entry is the discovery index entry for the candidate, and indexed
+	// reports that it was reused unchanged from the index.
+	entry   *indexEntry
+	indexed bool
+}
+
+func (r *Registry) concurrency() int {
+	if r.Concurrency <= 0 {
+		return 4 * runtime.GOMAXPROCS(0)
+	}
+	return r.Concurrency
+}
+
+// parseCandidates parses every candidate on a bounded pool of workers.
+func (r *Registry) parseCandidates(ctx context.Context, discovered []*discoveredSkill, index *discoveryIndex) {
+	workers := min(r.concurrency(), len(discovered))
+
+	next := make(chan *discoveredSkill)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for found := range next {
+				r.parseCandidate(ctx, found, index)
+			}
+		}()
+	}
+	for _, found := range discovered {
+		next <- found
+	}
+	close(next)
+	wg.Wait()
+}
+
+// parseCandidate reads a candidate's SKILL.md and, outside lazy mode, its
+// resources. In lazy mode only the front matter is read. Candidates with a
+// valid entry in the discovery index are not parsed again.
+func (r *Registry) parseCandidate(ctx context.Context, found *discoveredSkill, index *discoveryIndex) {
+	source, candidate := found.source, found.candidate
+	started := time.Now()
+	var info ResourceInfo
+	indexable := false
+	if index != nil {
+		var err error
+		info, err = source.Stat(candidate, "")
+		indexable = err == nil
+	}
+	if indexable {
+		if entry, updated := index.lookup(source, candidate, info); entry != nil {
+			r.restoreCandidate(found, entry, updated, started)
+			return
+		}
+	}
+
+	if isArchiveName(candidate) {
+		_, span := startSpan(ctx, "skillz.scan_archive")
+		defer span.End()
+		span.SetAttribute("skillz.archive", candidate)
+	}
+
+	reader, err := source.OpenSkillMarkdown(candidate)
+	if err != nil {
+		found.skipReason, found.err = skipReason(err), err
+		return
+	}
+	var raw string
+	if r.Lazy {
+		raw, err = readFrontMatter(reader)
+	} else {
+		var data []byte
+		data, err = io.ReadAll(reader)
+		raw = string(data)
+	}
+	_ = reader.Close()
+	if err != nil {
+		found.skipReason, found.err = "unreadable", err
+		return
+	}
+
+	metadata, body, err := parseSkillMarkdown(raw, skillMarkdownLocation(candidate))
+	if err != nil {
+		found.skipReason, found.err = "invalid_skill", err
+		return
+	}
+
+	skill := newSkill(source, candidate, metadata)
+	var resources []ResourceInfo
+	if !r.Lazy {
+		resources, err = source.ListResources(candidate)
+		if err != nil {
+			found.resourcesErr = err
+			return
+		}
+		skill.Instructions = body
+		skill.setResources(resources)
+	}
+	skill.MetadataLoadTime = time.Since(started)
+	found.skill = skill
+	if indexable {
+		found.entry = newIndexEntry(source, candidate, info, raw, skill, resources)
+	}
+}
+
+// restoreCandidate builds a candidate's skill from its discovery index entry.
+// Outside lazy mode the body is read from its recorded offset, and resources
+// are listed unless the entry holds them.
+func (r *Registry) restoreCandidate(found *discoveredSkill, entry *indexEntry, updated bool, started time.Time) {
+	source, candidate := found.source, found.candidate
+	skill := newSkill(source, candidate, entry.Metadata)
+	if !r.Lazy {
+		body, err := readSkillBody(source, candidate, entry.BodyOffset)
+		if err != nil {
+			found.skipReason, found.err = "unreadable", err
+			return
+		}
+		resources := entry.resources()
+		if entry.Resources == nil {
+			resources, err = source.ListResources(candidate)
+			if err != nil {
+				found.resourcesErr = err
+				return
+			}
+			listed := *entry
+			listed.setResources(source, candidate, resources)
+			entry, updated = &listed, true
+		}
+		skill.Instructions = body
+		skill.setResources(resources)
+	}
+	skill.MetadataLoadTime = time.Since(started)
+	found.skill = skill
+	found.entry, found.indexed = entry, !updated
+}
+
+// claim registers a parsed skill unless it is invalid or its name is already
+// taken.
+func (r *Registry) claim(snapshot *registrySnapshot, found *discoveredSkill) {
+	if found.skipReason != "" {
+		r.skipSkill(found.candidate, found.skipReason, found.err)
+		return
+	}
+	if !r.claimable(snapshot, found.candidate, found.skill.Metadata) {
+		return
+	}
+	if found.resourcesErr != nil {
+		r.skipSkill(found.candidate, "unreadable", found.resourcesErr)
+		return
+	}
+	snapshot.add(found.skill)
+}
+
+// skipReason classifies why a candidate's SKILL.md could not be opened.
+func skipReason(err error) string {
+	switch {
+	case errors.Is(err, errMissingSkillMarkdown):
+		return "missing_skill_md"
+	case errors.Is(err, errInvalidArchive):
+		return "invalid_archive"
+	default:
+		return "unreadable"
+	}
+}
+
+func skillMarkdownLocation(candidate string) string {
+	if isArchiveName(candidate) {
+		return candidate + ":" + SkillMarkdown
+	}
+	return filepath.Join(candidate, SkillMarkdown)
+}
+
+// claimable reports whether metadata's slug and name are still free; the
+// first skill discovered for either wins.
+func (r *Registry) claimable(snapshot *registrySnapshot, candidate string, metadata SkillMetadata) bool {
+	slug := slugify(metadata.Name)
+	if existing, exists := snapshot.bySlug[slug]; exists {
+		r.skipSkill(candidate, "duplicate_slug", fmt.Errorf("slug %q already provided by %s", slug, existing.candidate))
+		return false
+	}
+	if existing, exists := snapshot.byName[metadata.Name]; exists {
+		r.skipSkill(candidate, "duplicate_name", fmt.Errorf("name %q already provided by %s", metadata.Name, existing.candidate))
+		return false
+	}
+	return true
+}
+
+// newSkill describes a discovered candidate. Directory and ZipPath are local
+// paths for sources backed by local disk, and source-relative otherwise.
+func newSkill(source Source, candidate string, metadata SkillMetadata) Skill {
+	skill := Skill{
+		Slug:      slugify(metadata.Name),
+		Directory: candidate,
+		Metadata:  metadata,
+		Origin:    source.Name(),
+		src:       source,
+		candidate: candidate,
+	}
+	if isArchiveName(candidate) {
+		skill.ZipPath = candidate
+		skill.Directory = filepath.Dir(candidate)
+	}
+	if revisioned, ok := source.(revisionedSource); ok {
+		skill.Revision = revisioned.candidateRevision(candidate)
+	}
+	return skill
+}
+
+// setResources records the resources of a skill. Resources of directory
+// skills on local disk map to their absolute paths; other resources map to
+// their path within the skill.
+func (s *Skill) setResources(resources []ResourceInfo) {
+	local := ""
+	if rooted, ok := s.src.(interface{ localRoot() string }); ok && rooted.localRoot() != "" && !s.IsZip() {
+		local = s.Directory
+	}
+	s.Resources = make(map[string]string, len(resources))
+	for _, resource := range resources {
+		if local != "" {
+			s.Resources[resource.Path] = filepath.Join(local, filepath.FromSlash(resource.Path))
+		} else {
+			s.Resources[resource.Path] = resource.Path
+		}
+	}
+}
+
+// loadSkillContent reads the full SKILL.md and enumerates resources for a skill
+// that was discovered in lazy mode. The metadata parsed at discovery is kept:
+// the skill's slug and tool were registered from it, and only a reload may
+// change them.
+func loadSkillContent(skill *Skill) error {
+	if skill.src == nil {
+		return fs.ErrNotExist
+	}
+	reader, err := skill.src.OpenSkillMarkdown(skill.candidate)
+	if err != nil {
+		return err
+	}
+	raw, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		return err
+	}
+	_, body, err := parseSkillMarkdown(string(raw), skillMarkdownLocation(skill.candidate))
+	if err != nil {
+		return err
+	}
+	resources, err := skill.src.ListResources(skill.candidate)
+	if err != nil {
+		return err
+	}
+	skill.Instructions = body
+	skill.setResources(resources)
+	skill.directories = directoryTimes(skill.src, skill.candidate, resources)
+	return nil
+}
+
+// readFrontMatter reads only the leading YAML front matter block, stopping at
+// the closing delimiter so the body is never loaded.
+func readFrontMatter(reader io.Reader) (string, error) {
+	buffered := bufio.NewReader(reader)
+	var builder strings.Builder
+	for lineNumber := 0; ; lineNumber++ {
+		line, err := buffered.ReadString('\n')
+		builder.WriteString(line)
+		delimiter := strings.TrimSpace(line) == "---"
+		if lineNumber == 0 && !delimiter {
+			break
+		}
+		if lineNumber > 0 && delimiter && strings.HasSuffix(line, "\n") {
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
+
+func (s Skill) OpenBytes(relPath string) ([]byte, error) {
+	reader, _, err := s.OpenReader(relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// OpenReader opens a resource for streaming and reports its uncompressed size.
+// Resources of directory skills on disk are returned as *os.File so callers
+// can seek.
+func (s Skill) OpenReader(relPath string) (io.ReadCloser, int64, error) {
+	relPath = normalizeRelPath(relPath)
+	if s.src == nil || !s.HasResource(relPath) {
+		return nil, 0, os.ErrNotExist
+	}
+	return s.src.OpenResource(s.candidate, relPath)
+}
+
+// ResourceInfos reports the size and modification time of every resource,
+// reading archive directory entries instead of extracting members.
+func (s Skill) ResourceInfos() ([]ResourceInfo, error) {
+	if s.src == nil {
+		return []ResourceInfo{}, nil
+	}
+	listed, err := s.src.ListResources(s.candidate)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ResourceInfo, 0, len(listed))
+	for _, info := range listed {
+		if s.HasResource(info.Path) {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (s Skill) HasResource(relPath string) bool {
+	relPath = normalizeRelPath(relPath)
+	_, ok := s.Resources[relPath]
+	return ok
+}
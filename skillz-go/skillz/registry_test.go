@@ -0,0 +1,406 @@
+package skillz
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSkill is the skill written by writeSkill and createZipSkill. Options
+// adjust its front matter and add resources.
+type testSkill struct {
+	name        string
+	description string
+	tags        string
+	body        string
+	files       map[string]string
+}
+
+type skillOption func(*testSkill)
+
+// withName sets the skill name when it differs from the directory name.
+func withName(name string) skillOption {
+	return func(skill *testSkill) { skill.name = name }
+}
+
+func withDescription(description string) skillOption {
+	return func(skill *testSkill) { skill.description = description }
+}
+
+// withTags sets the tags list, e.g. "public, docs".
+func withTags(tags string) skillOption {
+	return func(skill *testSkill) { skill.tags = tags }
+}
+
+func withBody(body string) skillOption {
+	return func(skill *testSkill) { skill.body = body }
+}
+
+// withFile adds a resource at the slash-separated path name.
+func withFile(name string, content string) skillOption {
+	return func(skill *testSkill) { skill.files[name] = content }
+}
+
+func newTestSkill(name string, options []skillOption) *testSkill {
+	skill := &testSkill{name: name, description: "Test skill", body: "Body\n", files: map[string]string{}}
+	for _, option := range options {
+		option(skill)
+	}
+	return skill
+}
+
+func (s *testSkill) markdown() string {
+	content := "---\nname: " + s.name + "\ndescription: " + s.description + "\n"
+	if s.tags != "" {
+		content += "tags: [" + s.tags + "]\n"
+	}
+	return content + "---\n" + s.body
+}
+
+// skillMarkdown renders the SKILL.md writeSkill would write, for fixtures
+// that are not plain directories.
+func skillMarkdown(name string, options ...skillOption) string {
+	return newTestSkill(name, options).markdown()
+}
+
+func writeSkill(tb testing.TB, root string, name string, options ...skillOption) string {
+	tb.Helper()
+	skill := newTestSkill(name, options)
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		tb.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SkillMarkdown), []byte(skill.markdown()), 0o644); err != nil {
+		tb.Fatalf("write skill: %v", err)
+	}
+	for file, content := range skill.files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			tb.Fatalf("write resource: %v", err)
+		}
+	}
+	return dir
+}
+
+// createZipSkill writes a zip archive holding SKILL.md and text/hello.txt at
+// its root, plus any files added by options.
+func createZipSkill(tb testing.TB, zipPath string, name string, options ...skillOption) {
+	tb.Helper()
+	defaults := []skillOption{withDescription("Test skill from zip"), withBody("Zip Body\n"), withFile("text/hello.txt", "hello")}
+	skill := newTestSkill(name, append(defaults, options...))
+	file, err := os.Create(zipPath)
+	if err != nil {
+		tb.Fatalf("create zip file: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	skillMD, err := writer.Create(SkillMarkdown)
+	if err != nil {
+		tb.Fatalf("create SKILL.md: %v", err)
+	}
+	if _, err := skillMD.Write([]byte(skill.markdown())); err != nil {
+		tb.Fatalf("write SKILL.md: %v", err)
+	}
+	names := make([]string, 0, len(skill.files))
+	for name := range skill.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resource, err := writer.Create(name)
+		if err != nil {
+			tb.Fatalf("create resource: %v", err)
+		}
+		if _, err := resource.Write([]byte(skill.files[name])); err != nil {
+			tb.Fatalf("write resource: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		tb.Fatalf("close zip: %v", err)
+	}
+}
+
+func TestRegistryDiscoversDirectorySkill(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	skill, err := registry.Get("echo")
+	if err != nil {
+		t.Fatalf("get skill: %v", err)
+	}
+	if skill.Metadata.Name != "echo" {
+		t.Fatalf("unexpected name: %s", skill.Metadata.Name)
+	}
+}
+
+func TestRegistryDiscoversZipSkill(t *testing.T) {
+	temp := t.TempDir()
+	zipPath := filepath.Join(temp, "my-skill.zip")
+	createZipSkill(t, zipPath, "MySkill")
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	skill, err := registry.Get("myskill")
+	if err != nil {
+		t.Fatalf("get skill: %v", err)
+	}
+	if !skill.IsZip() {
+		t.Fatalf("expected zip skill")
+	}
+	if !skill.HasResource("text/hello.txt") {
+		t.Fatalf("expected text resource")
+	}
+}
+
+func TestLazyRegistryDefersContentUntilResolve(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "echo")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644); err != nil {
+		t.Fatalf("write resource: %v", err)
+	}
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	skill, err := registry.Get("echo")
+	if err != nil {
+		t.Fatalf("get skill: %v", err)
+	}
+	if skill.Instructions != "" || len(skill.Resources) != 0 {
+		t.Fatalf("expected content to be deferred, got %q and %v", skill.Instructions, skill.Resources)
+	}
+
+	skill, err = registry.Resolve("echo")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if skill.Instructions != "Body\n" {
+		t.Fatalf("unexpected instructions: %q", skill.Instructions)
+	}
+	if !skill.HasResource("notes.txt") {
+		t.Fatalf("expected notes.txt resource")
+	}
+}
+
+func TestLazyRegistryReloadsWhenModified(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "echo")
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := registry.Resolve("echo"); err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+
+	skillMD := filepath.Join(dir, SkillMarkdown)
+	content := "---\nname: echo\ndescription: Test skill\n---\nUpdated\n"
+	if err := os.WriteFile(skillMD, []byte(content), 0o644); err != nil {
+		t.Fatalf("rewrite skill: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(skillMD, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	skill, err := registry.Resolve("echo")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if skill.Instructions != "Updated\n" {
+		t.Fatalf("expected reloaded instructions, got %q", skill.Instructions)
+	}
+}
+
+func TestLazyRegistryReloadsWhenNestedResourceChanges(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "echo")
+	scripts := filepath.Join(dir, "scripts")
+	if err := os.MkdirAll(scripts, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	script := filepath.Join(scripts, "run.py")
+	if err := os.WriteFile(script, []byte("print(1)\n"), 0o644); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := registry.Resolve("echo"); err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+
+	// Neither SKILL.md nor the skill directory changes.
+	future := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(scripts, "helper.py"), []byte("pass\n"), 0o644); err != nil {
+		t.Fatalf("write helper: %v", err)
+	}
+	if err := os.WriteFile(script, []byte("print('edited')\n"), 0o644); err != nil {
+		t.Fatalf("edit script: %v", err)
+	}
+	for _, changed := range []string{scripts, script} {
+		if err := os.Chtimes(changed, future, future); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	skill, err := registry.Resolve("echo")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if !skill.HasResource("scripts/helper.py") {
+		t.Fatalf("expected the new nested resource, got %v", skill.Resources)
+	}
+	infos, err := skill.ResourceInfos()
+	if err != nil {
+		t.Fatalf("resource infos: %v", err)
+	}
+	for _, info := range infos {
+		if info.Path == "scripts/run.py" && info.Size != int64(len("print('edited')\n")) {
+			t.Fatalf("expected the edited size, got %d", info.Size)
+		}
+	}
+}
+
+func TestLazyRegistryKeepsDiscoveredMetadata(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "echo")
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// A rename takes effect on the next Load, which registers the new tool.
+	content := "---\nname: renamed\ndescription: Renamed skill\n---\nNew body\n"
+	if err := os.WriteFile(filepath.Join(dir, SkillMarkdown), []byte(content), 0o644); err != nil {
+		t.Fatalf("rewrite skill: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, SkillMarkdown), future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	skill, err := registry.Resolve("echo")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if skill.Metadata.Name != "echo" || skill.Instructions != "New body\n" {
+		t.Fatalf("expected the new body under the discovered name, got %q %q", skill.Metadata.Name, skill.Instructions)
+	}
+}
+
+func TestLazyRegistryResolvesZipSkill(t *testing.T) {
+	temp := t.TempDir()
+	createZipSkill(t, filepath.Join(temp, "my-skill.zip"), "MySkill")
+
+	registry := NewRegistry(temp)
+	registry.Lazy = true
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	skill, err := registry.Resolve("myskill")
+	if err != nil {
+		t.Fatalf("resolve skill: %v", err)
+	}
+	if skill.Instructions != "Zip Body\n" {
+		t.Fatalf("unexpected instructions: %q", skill.Instructions)
+	}
+	data, err := skill.OpenBytes("text/hello.txt")
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected resource: %q, %v", data, err)
+	}
+}
+
+func TestRegistryReloadsDuringConcurrentReads(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		temp := t.TempDir()
+		writeSkillWithResources(t, temp)
+		writeSkill(t, temp, "echo")
+		registry := NewRegistry(temp)
+		registry.Lazy = lazy
+		if err := registry.Load(); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		mcpServer := NewServer(registry)
+
+		done := make(chan struct{})
+		var readers sync.WaitGroup
+		for reader := 0; reader < 4; reader++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					if skills := registry.Skills(); len(skills) < 2 {
+						t.Errorf("lazy=%v: reader saw %d skills mid-reload", lazy, len(skills))
+						return
+					}
+					if _, err := registry.Resolve("testskill"); err != nil {
+						t.Errorf("lazy=%v resolve: %v", lazy, err)
+						return
+					}
+					result := FetchResourceJSON(registry, "resource://skillz/testskill/script.py")
+					if result["content"] != "print('hello')" {
+						t.Errorf("lazy=%v fetch: %v", lazy, result)
+						return
+					}
+					mcpServer.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"task":"t"}}}`))
+				}
+			}()
+		}
+
+		var loaders sync.WaitGroup
+		for loader := 0; loader < 2; loader++ {
+			loaders.Add(1)
+			go func(loader int) {
+				defer loaders.Done()
+				for iteration := 0; iteration < 20; iteration++ {
+					name := fmt.Sprintf("extra-%d-%d", loader, iteration)
+					writeSkill(t, temp, name)
+					if err := registry.Load(); err != nil {
+						t.Errorf("lazy=%v reload: %v", lazy, err)
+						return
+					}
+					if err := os.RemoveAll(filepath.Join(temp, name)); err != nil {
+						t.Errorf("remove: %v", err)
+						return
+					}
+				}
+			}(loader)
+		}
+		loaders.Wait()
+		close(done)
+		readers.Wait()
+	}
+}
@@ -0,0 +1,369 @@
+package skillz
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"net/url"
+)
+
+func BuildResourceURI(skill Skill, relPath string) string {
+	slug := url.PathEscape(skill.Slug)
+	relPath = normalizeRelPath(relPath)
+	parts := strings.Split(relPath, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return "resource://skillz/" + slug + "/" + strings.Join(parts, "/")
+}
+
+// Error codes reported in the error_code field of failed fetches.
+const (
+	errorCodeMissingURI       = "missing_uri"
+	errorCodeInvalidURI       = "invalid_uri"
+	errorCodeInvalidRange     = "invalid_range"
+	errorCodeSkillNotFound    = "skill_not_found"
+	errorCodeResourceNotFound = "resource_not_found"
+	errorCodeReadFailed       = "read_failed"
+	errorCodeRejected         = "rejected"
+)
+
+func makeErrorResource(resourceURI string, code string, message string) map[string]any {
+	name := "invalid resource"
+	if strings.HasPrefix(resourceURI, "resource://skillz/") {
+		pathPart := strings.TrimPrefix(resourceURI, "resource://skillz/")
+		if pathPart != "" {
+			name = pathPart
+		}
+	}
+	return map[string]any{
+		"uri":        resourceURI,
+		"name":       name,
+		"mime_type":  "text/plain",
+		"content":    "Error: " + message,
+		"encoding":   "utf-8",
+		"error_code": code,
+	}
+}
+
+// defaultMaxPayloadBytes caps the content returned by a single fetch or
+// resource read when no explicit limit is configured.
+const defaultMaxPayloadBytes = 1 << 20
+
+type FetchOptions struct {
+	// Offset and Length select a byte range; a zero Length reads to the end.
+	Offset int64
+	Length int64
+	// StartLine and EndLine select an inclusive, 1-based line range and take
+	// precedence over the byte range when StartLine is set.
+	StartLine int
+	EndLine   int
+	// MaxBytes caps the returned content, measured after binary content is
+	// base64-encoded; larger ranges are truncated and a next_offset cursor is
+	// reported. Zero means defaultMaxPayloadBytes.
+	MaxBytes int64
+	// Authorize, when set, hides skills the caller may not read; they are
+	// reported as not found.
+	Authorize func(Skill) bool
+
+	// interceptor runs the server's middleware around the read.
+	interceptor *resourceInterceptor
+}
+
+type resourceChunk struct {
+	data      []byte
+	size      int64
+	offset    int64
+	truncated bool
+	startLine int
+	endLine   int
+	nextLine  int
+}
+
+func (c resourceChunk) end() int64 {
+	return c.offset + int64(len(c.data))
+}
+
+func FetchResourceJSON(registry *Registry, resourceURI string) map[string]any {
+	return FetchResourceJSONWithOptions(registry, resourceURI, FetchOptions{})
+}
+
+func FetchResourceJSONWithOptions(registry *Registry, resourceURI string, options FetchOptions) map[string]any {
+	slug, relPath, err := parseResourceURI(resourceURI)
+	if err != nil {
+		return makeErrorResource(resourceURI, errorCodeInvalidURI, err.Error())
+	}
+	if options.Offset < 0 || options.Length < 0 || options.StartLine < 0 || options.EndLine < 0 {
+		return makeErrorResource(resourceURI, errorCodeInvalidRange, "offset, length, start_line and end_line must not be negative")
+	}
+	if options.StartLine > 0 && options.EndLine > 0 && options.EndLine < options.StartLine {
+		return makeErrorResource(resourceURI, errorCodeInvalidRange, "end_line must not be before start_line")
+	}
+
+	skill, err := registry.Resolve(slug)
+	if err != nil || (options.Authorize != nil && !options.Authorize(skill)) {
+		return makeErrorResource(resourceURI, errorCodeSkillNotFound, "skill not found: "+slug)
+	}
+	if !skill.HasResource(relPath) {
+		return makeErrorResource(resourceURI, errorCodeResourceNotFound, "resource not found: "+relPath)
+	}
+
+	var read *ResourceRead
+	if options.interceptor != nil {
+		if read, err = options.interceptor.before(skill, relPath, resourceURI); err != nil {
+			return makeErrorResource(resourceURI, errorCodeRejected, err.Error())
+		}
+	}
+	chunk, err := readResourceChunk(skill, relPath, options)
+	if err != nil {
+		return makeErrorResource(resourceURI, errorCodeReadFailed, "failed to read resource: "+err.Error())
+	}
+	data := chunk.data
+	if read != nil {
+		if data, err = options.interceptor.after(read, data); err != nil {
+			return makeErrorResource(resourceURI, errorCodeRejected, err.Error())
+		}
+	}
+
+	mimeType := detectMimeType(relPath)
+	content := ""
+	encoding := "utf-8"
+	if utf8.Valid(data) {
+		content = string(data)
+	} else {
+		content = base64.StdEncoding.EncodeToString(data)
+		encoding = "base64"
+	}
+
+	// length is the span of the resource read, which next_offset continues
+	// from; returned_bytes is what middleware left of it.
+	result := map[string]any{
+		"uri":            resourceURI,
+		"name":           skill.ResourceName(relPath),
+		"mime_type":      mimeType,
+		"content":        content,
+		"encoding":       encoding,
+		"size":           chunk.size,
+		"offset":         chunk.offset,
+		"length":         len(chunk.data),
+		"returned_bytes": len(data),
+		"next_offset":    nil,
+		"truncated":      chunk.truncated,
+	}
+	if chunk.end() < chunk.size {
+		result["next_offset"] = chunk.end()
+	}
+	if options.StartLine > 0 {
+		result["start_line"] = chunk.startLine
+		result["end_line"] = chunk.endLine
+		result["next_line"] = nil
+		if chunk.nextLine > 0 {
+			result["next_line"] = chunk.nextLine
+		}
+	}
+	if chunk.truncated {
+		result["notice"] = truncationNotice(chunk)
+	}
+	return result
+}
+
+func truncationNotice(chunk resourceChunk) string {
+	notice := fmt.Sprintf("[truncated: returned %d of %d bytes starting at offset %d", len(chunk.data), chunk.size, chunk.offset)
+	if chunk.nextLine > 0 {
+		return notice + fmt.Sprintf("; continue with start_line=%d]", chunk.nextLine)
+	}
+	return notice + fmt.Sprintf("; continue with offset=%d]", chunk.end())
+}
+
+func readResourceChunk(skill Skill, relPath string, options FetchOptions) (resourceChunk, error) {
+	maxBytes := options.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxPayloadBytes
+	}
+
+	reader, size, err := skill.OpenReader(relPath)
+	if err != nil {
+		return resourceChunk{}, err
+	}
+	defer reader.Close()
+
+	if options.StartLine > 0 {
+		return readLineRange(reader, size, options.StartLine, options.EndLine, maxBytes)
+	}
+
+	offset := min(options.Offset, size)
+	if err := skipTo(reader, offset); err != nil {
+		return resourceChunk{}, err
+	}
+	wanted := size - offset
+	if options.Length > 0 {
+		wanted = min(wanted, options.Length)
+	}
+	take := min(wanted, maxBytes)
+
+	data := make([]byte, take)
+	read, err := io.ReadFull(reader, data)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return resourceChunk{}, err
+	}
+	data = data[:read]
+	if offset+int64(len(data)) < size {
+		data = trimPartialRune(data)
+	}
+	truncated := take < wanted
+	if budget := base64Budget(maxBytes); int64(len(data)) > budget && !utf8.Valid(data) {
+		data, truncated = data[:budget], true
+	}
+	return resourceChunk{
+		data:      data,
+		size:      size,
+		offset:    offset,
+		truncated: truncated,
+	}, nil
+}
+
+// base64Budget is the most raw bytes whose base64 encoding fits in maxBytes.
+func base64Budget(maxBytes int64) int64 {
+	return maxBytes / 4 * 3
+}
+
+func readLineRange(reader io.Reader, size int64, startLine int, endLine int, maxBytes int64) (resourceChunk, error) {
+	buffered := bufio.NewReader(reader)
+	chunk := resourceChunk{size: size, startLine: startLine}
+	var position int64
+	var data []byte
+	// Lines never split a UTF-8 sequence, so the chunk is text exactly when
+	// every line is.
+	text := true
+	line := 0
+	for {
+		current, err := buffered.ReadBytes('\n')
+		if len(current) > 0 {
+			line++
+			if line == startLine {
+				chunk.offset = position
+			}
+			if line >= startLine {
+				limit := maxBytes
+				if text = text && utf8.Valid(current); !text {
+					limit = base64Budget(maxBytes)
+				}
+				if int64(len(data)+len(current)) > limit {
+					chunk.truncated = true
+					break
+				}
+				data = append(data, current...)
+				chunk.endLine = line
+			}
+			position += int64(len(current))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return resourceChunk{}, err
+		}
+		if endLine > 0 && line >= endLine {
+			break
+		}
+	}
+	if line < startLine {
+		chunk.offset = position
+	}
+	if chunk.truncated && len(data) == 0 {
+		return resourceChunk{}, fmt.Errorf("line %d exceeds the maximum payload of %d bytes; use offset and length instead", startLine, maxBytes)
+	}
+
+	chunk.data = data
+	if chunk.end() < size {
+		chunk.nextLine = chunk.endLine + 1
+	}
+	return chunk, nil
+}
+
+func skipTo(reader io.Reader, offset int64) error {
+	if offset == 0 {
+		return nil
+	}
+	if seeker, ok := reader.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, reader, offset)
+	return err
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence at the end of a text
+// chunk so that a cut inside a multi-byte character does not turn the whole
+// chunk into base64.
+func trimPartialRune(data []byte) []byte {
+	for cut := 1; cut <= utf8.UTFMax-1 && cut <= len(data); cut++ {
+		if utf8.RuneStart(data[len(data)-cut]) {
+			if !utf8.FullRune(data[len(data)-cut:]) && utf8.Valid(data[:len(data)-cut]) {
+				return data[:len(data)-cut]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func parseResourceURI(resourceURI string) (string, string, error) {
+	const prefix = "resource://skillz/"
+	if !strings.HasPrefix(resourceURI, prefix) {
+		return "", "", errors.New("unsupported URI prefix. Expected resource://skillz/{skill-slug}/{path}")
+	}
+
+	remainder := strings.TrimPrefix(resourceURI, prefix)
+	if remainder == "" {
+		return "", "", errors.New("invalid resource URI format")
+	}
+
+	parts := strings.SplitN(remainder, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("invalid resource URI format")
+	}
+
+	slug, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", errors.New("invalid skill slug encoding")
+	}
+	relPath, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", errors.New("invalid resource path encoding")
+	}
+	relPath = normalizeRelPath(relPath)
+	if strings.HasPrefix(relPath, "/") || strings.Contains(relPath, "..") {
+		return "", "", errors.New("invalid path: path traversal not allowed")
+	}
+	return slug, relPath, nil
+}
+
+func detectMimeType(relPath string) any {
+	ext := strings.ToLower(path.Ext(relPath))
+	mimeType := mime.TypeByExtension(ext)
+	if idx := strings.Index(mimeType, ";"); idx > -1 {
+		mimeType = mimeType[:idx]
+	}
+	if mimeType != "" {
+		return mimeType
+	}
+
+	switch ext {
+	case ".py":
+		return "text/x-python"
+	case ".md":
+		return "text/markdown"
+	case ".txt":
+		return "text/plain"
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package skillz
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSkillWithResources(t *testing.T, root string) {
+	t.Helper()
+	writeSkill(t, root, "testskill",
+		withName("TestSkill"),
+		withDescription("Test skill with resources"),
+		withFile("script.py", "print('hello')"),
+		withFile("data.bin", "\xff\xfe\x00\x01\x80\x90"),
+	)
+}
+
+func TestFetchTextResource(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result := FetchResourceJSON(registry, "resource://skillz/testskill/script.py")
+	if result["encoding"] != "utf-8" {
+		t.Fatalf("expected utf-8 encoding")
+	}
+	if result["content"] != "print('hello')" {
+		t.Fatalf("unexpected content: %v", result["content"])
+	}
+}
+
+func TestFetchBinaryResource(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result := FetchResourceJSON(registry, "resource://skillz/testskill/data.bin")
+	if result["encoding"] != "base64" {
+		t.Fatalf("expected base64 encoding")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(result["content"].(string))
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	expected := []byte{0xff, 0xfe, 0x00, 0x01, 0x80, 0x90}
+	if string(decoded) != string(expected) {
+		t.Fatalf("unexpected binary content")
+	}
+}
+
+func TestFetchRejectsPathTraversal(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result := FetchResourceJSON(registry, "resource://skillz/testskill/../../../etc/passwd")
+	content := result["content"].(string)
+	if content == "" || content[:6] != "Error:" {
+		t.Fatalf("expected error content")
+	}
+}
+
+func loadRangeRegistry(t *testing.T) *Registry {
+	t.Helper()
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	lines := "one\ntwo\nthree\nfour\n"
+	if err := os.WriteFile(filepath.Join(temp, "testskill", "lines.txt"), []byte(lines), 0o644); err != nil {
+		t.Fatalf("write lines: %v", err)
+	}
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return registry
+}
+
+func TestFetchByteRangeReportsCursor(t *testing.T) {
+	registry := loadRangeRegistry(t)
+
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/testskill/lines.txt", FetchOptions{Offset: 4, Length: 4})
+	if result["content"] != "two\n" {
+		t.Fatalf("unexpected content: %q", result["content"])
+	}
+	if result["size"] != int64(19) || result["next_offset"] != int64(8) {
+		t.Fatalf("unexpected size/cursor: %v %v", result["size"], result["next_offset"])
+	}
+	if result["truncated"] != false {
+		t.Fatalf("explicit length should not be reported as truncated")
+	}
+}
+
+func TestFetchLineRange(t *testing.T) {
+	registry := loadRangeRegistry(t)
+
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/testskill/lines.txt", FetchOptions{StartLine: 2, EndLine: 3})
+	if result["content"] != "two\nthree\n" {
+		t.Fatalf("unexpected content: %q", result["content"])
+	}
+	if result["next_line"] != 4 || result["next_offset"] != int64(14) {
+		t.Fatalf("unexpected cursors: %v %v", result["next_line"], result["next_offset"])
+	}
+
+	result = FetchResourceJSONWithOptions(registry, "resource://skillz/testskill/lines.txt", FetchOptions{StartLine: 3})
+	if result["content"] != "three\nfour\n" || result["next_line"] != nil {
+		t.Fatalf("unexpected tail: %q %v", result["content"], result["next_line"])
+	}
+}
+
+func TestFetchTruncatesAtMaxPayload(t *testing.T) {
+	registry := loadRangeRegistry(t)
+
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/testskill/lines.txt", FetchOptions{MaxBytes: 6})
+	if result["content"] != "one\ntw" || result["truncated"] != true {
+		t.Fatalf("unexpected truncation: %q %v", result["content"], result["truncated"])
+	}
+	if result["next_offset"] != int64(6) || result["notice"] == nil {
+		t.Fatalf("expected cursor and notice, got %v %v", result["next_offset"], result["notice"])
+	}
+}
+
+func TestFetchCapsEncodedBinaryPayload(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Six bytes fit the cap but their base64 encoding does not.
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/testskill/data.bin", FetchOptions{MaxBytes: 6})
+	content := result["content"].(string)
+	if result["encoding"] != "base64" || len(content) > 6 || result["truncated"] != true {
+		t.Fatalf("expected base64 content within the cap, got %q truncated=%v", content, result["truncated"])
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(content); string(decoded) != "\xff\xfe\x00" || result["next_offset"] != int64(3) {
+		t.Fatalf("unexpected chunk %v, next_offset %v", decoded, result["next_offset"])
+	}
+}
+
+func TestFetchRangeFromZipMember(t *testing.T) {
+	temp := t.TempDir()
+	createZipSkill(t, filepath.Join(temp, "my-skill.zip"), "MySkill")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result := FetchResourceJSONWithOptions(registry, "resource://skillz/myskill/text/hello.txt", FetchOptions{Offset: 1, Length: 3})
+	if result["content"] != "ell" || result["next_offset"] != int64(4) {
+		t.Fatalf("unexpected zip range: %q %v", result["content"], result["next_offset"])
+	}
+}
+
+func TestTrimPartialRuneKeepsTextEncoding(t *testing.T) {
+	data := []byte("caf\xc3")
+	if got := string(trimPartialRune(data)); got != "caf" {
+		t.Fatalf("unexpected trim: %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package skillz
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchResults = 50
+	defaultSearchContext = 2
+	// maxSearchFileBytes skips resources too large to scan line by line.
+	maxSearchFileBytes = 16 << 20
+)
+
+type SearchOptions struct {
+	// Skill restricts the search to one skill slug; empty searches all skills.
+	Skill        string
+	Pattern      string
+	Glob         string
+	MaxResults   int
+	ContextLines int
+	// Authorize, when set, skips skills the caller may not read.
+	Authorize func(Skill) bool
+
+	// interceptor runs the server's middleware around the read of each
+	// searched resource.
+	interceptor *resourceInterceptor
+}
+
+type SearchMatch struct {
+	URI    string   `json:"uri"`
+	Skill  string   `json:"skill"`
+	Path   string   `json:"path"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+type SearchResult struct {
+	Matches   []SearchMatch `json:"matches"`
+	Truncated bool          `json:"truncated"`
+	Searched  int           `json:"files_searched"`
+}
+
+// SearchResources runs a regular expression over the text resources of one or
+// all skills and returns matching lines with surrounding context.
+func SearchResources(registry *Registry, options SearchOptions) (SearchResult, error) {
+	pattern, err := regexp.Compile(options.Pattern)
+	if err != nil {
+		return SearchResult{}, SkillError{Code: "validation_error", Message: fmt.Sprintf("invalid pattern: %v", err)}
+	}
+	if options.Glob != "" {
+		if _, err := path.Match(options.Glob, ""); err != nil {
+			return SearchResult{}, SkillError{Code: "validation_error", Message: fmt.Sprintf("invalid glob: %v", err)}
+		}
+	}
+	maxResults := options.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultSearchResults
+	}
+	contextLines := max(options.ContextLines, 0)
+
+	slugs := []string{}
+	if options.Skill != "" {
+		slugs = append(slugs, options.Skill)
+	} else {
+		for _, skill := range registry.Skills() {
+			slugs = append(slugs, skill.Slug)
+		}
+	}
+
+	result := SearchResult{Matches: []SearchMatch{}}
+	for _, slug := range slugs {
+		skill, err := registry.Resolve(slug)
+		if err == nil && options.Authorize != nil && !options.Authorize(skill) {
+			if options.Skill == "" {
+				continue
+			}
+			err = SkillError{Code: "skill_error", Message: fmt.Sprintf("unknown skill '%s'", slug)}
+		}
+		if err != nil {
+			return SearchResult{}, err
+		}
+		for _, relPath := range sortedKeys(skill.Resources) {
+			if !matchesGlob(options.Glob, relPath) {
+				continue
+			}
+			remaining := maxResults - len(result.Matches)
+			matches, searched, more, err := searchResource(skill, relPath, pattern, contextLines, remaining, options.interceptor)
+			if err != nil {
+				continue
+			}
+			if searched {
+				result.Searched++
+			}
+			result.Matches = append(result.Matches, matches...)
+			if more {
+				result.Truncated = true
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+// matchesGlob matches patterns without a slash against the file name and
+// patterns with a slash against the full resource path.
+func matchesGlob(glob string, relPath string) bool {
+	if glob == "" {
+		return true
+	}
+	target := relPath
+	if !strings.Contains(glob, "/") {
+		target = path.Base(relPath)
+	}
+	matched, _ := path.Match(glob, target)
+	return matched
+}
+
+// searchResource matches the lines of one resource. Resources rejected by the
+// interceptor are skipped, and lines are matched against the data its After
+// hooks return, so redactions apply to search results too.
+func searchResource(skill Skill, relPath string, pattern *regexp.Regexp, contextLines int, limit int, interceptor *resourceInterceptor) ([]SearchMatch, bool, bool, error) {
+	uri := BuildResourceURI(skill, relPath)
+	var read *ResourceRead
+	if interceptor != nil {
+		var err error
+		if read, err = interceptor.before(skill, relPath, uri); err != nil {
+			return nil, false, false, err
+		}
+	}
+	reader, size, err := skill.OpenReader(relPath)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer reader.Close()
+	if size > maxSearchFileBytes {
+		return nil, false, false, nil
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if interceptor != nil {
+		if data, err = interceptor.after(read, data); err != nil {
+			return nil, false, false, err
+		}
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return nil, false, false, nil
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, false, err
+	}
+
+	matches := []SearchMatch{}
+	for index, line := range lines {
+		if !pattern.MatchString(line) {
+			continue
+		}
+		if len(matches) == limit {
+			return matches, true, true, nil
+		}
+		before := lines[max(0, index-contextLines):index]
+		after := lines[index+1 : min(len(lines), index+1+contextLines)]
+		matches = append(matches, SearchMatch{
+			URI:    uri,
+			Skill:  skill.Slug,
+			Path:   relPath,
+			Line:   index + 1,
+			Text:   line,
+			Before: append([]string{}, before...),
+			After:  append([]string{}, after...),
+		})
+	}
+	return matches, true, false, nil
+}
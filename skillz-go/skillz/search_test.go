@@ -0,0 +1,72 @@
+package skillz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchResourcesReturnsMatchesWithContext(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "docs")
+	reference := "alpha\nbeta\nneedle here\ngamma\ndelta\n"
+	if err := os.WriteFile(filepath.Join(dir, "reference.md"), []byte(reference), 0o644); err != nil {
+		t.Fatalf("write reference: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("needle too\n"), 0o644); err != nil {
+		t.Fatalf("write other: %v", err)
+	}
+	createZipSkill(t, filepath.Join(temp, "zipped.zip"), "Zipped")
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result, err := SearchResources(registry, SearchOptions{Pattern: "needle", Glob: "*.md", ContextLines: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Matches) != 1 {
+		t.Fatalf("expected 1 match, got %v", result.Matches)
+	}
+	match := result.Matches[0]
+	if match.Line != 3 || match.URI != "resource://skillz/docs/reference.md" {
+		t.Fatalf("unexpected match: %+v", match)
+	}
+	if len(match.Before) != 1 || match.Before[0] != "beta" || match.After[0] != "gamma" {
+		t.Fatalf("unexpected context: %+v", match)
+	}
+
+	result, err = SearchResources(registry, SearchOptions{Skill: "zipped", Pattern: "^hel+o$"})
+	if err != nil {
+		t.Fatalf("search zip: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].Path != "text/hello.txt" {
+		t.Fatalf("expected zip member match, got %v", result.Matches)
+	}
+}
+
+func TestSearchResourcesStopsAtMaxResults(t *testing.T) {
+	temp := t.TempDir()
+	dir := writeSkill(t, temp, "docs")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x\nx\nx\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	result, err := SearchResources(registry, SearchOptions{Pattern: "x", MaxResults: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Matches) != 2 || !result.Truncated {
+		t.Fatalf("expected 2 truncated matches, got %d %v", len(result.Matches), result.Truncated)
+	}
+	if _, err := SearchResources(registry, SearchOptions{Pattern: "("}); err == nil {
+		t.Fatalf("expected invalid pattern error")
+	}
+}
@@ -0,0 +1,178 @@
+package skillz
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxInlineInstructionBytes is the body size above which the skill tool returns
+// a table of contents instead of the full instructions.
+const maxInlineInstructionBytes = 32 * 1024
+
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+
+type markdownSection struct {
+	Number string
+	Title  string
+	Anchor string
+	Level  int
+	Start  int
+	End    int
+}
+
+type SectionEntry struct {
+	Number string `json:"number"`
+	Title  string `json:"title"`
+	Anchor string `json:"anchor"`
+	Level  int    `json:"level"`
+	Bytes  int    `json:"bytes"`
+}
+
+// parseMarkdownSections returns the ATX headings of body in document order.
+// Each section spans from its heading to the next heading of the same or a
+// higher level; headings inside fenced code blocks are ignored. Repeated
+// anchors get -1, -2, ... suffixes as on GitHub, so every number and anchor
+// selects one section.
+func parseMarkdownSections(body string) []markdownSection {
+	sections := []markdownSection{}
+	levels := []int{}
+	counters := []int{}
+	anchors := map[string]bool{}
+	fence := ""
+	offset := 0
+	for _, line := range strings.SplitAfter(body, "\n") {
+		lineStart := offset
+		offset += len(line)
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		stripped := strings.TrimLeft(trimmed, " ")
+		if fence != "" {
+			if strings.HasPrefix(stripped, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(stripped, "```") || strings.HasPrefix(stripped, "~~~") {
+			fence = stripped[:3]
+			continue
+		}
+
+		match := headingPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+		level := len(match[1])
+		// A heading that closes deeper sections without matching their level,
+		// like ## after ###, continues the numbering of the closed depth.
+		previous := 0
+		for len(levels) > 0 && levels[len(levels)-1] > level {
+			previous = counters[len(counters)-1]
+			levels = levels[:len(levels)-1]
+			counters = counters[:len(counters)-1]
+		}
+		if len(levels) > 0 && levels[len(levels)-1] == level {
+			counters[len(counters)-1]++
+		} else {
+			levels = append(levels, level)
+			counters = append(counters, previous+1)
+		}
+
+		parts := make([]string, len(counters))
+		for i, counter := range counters {
+			parts[i] = strconv.Itoa(counter)
+		}
+		sections = append(sections, markdownSection{
+			Number: strings.Join(parts, "."),
+			Title:  match[2],
+			Anchor: uniqueAnchor(anchors, slugify(match[2])),
+			Level:  level,
+			Start:  lineStart,
+			End:    len(body),
+		})
+	}
+
+	for i := range sections {
+		for j := i + 1; j < len(sections); j++ {
+			if sections[j].Level <= sections[i].Level {
+				sections[i].End = sections[j].Start
+				break
+			}
+		}
+	}
+	return sections
+}
+
+func uniqueAnchor(used map[string]bool, anchor string) string {
+	unique := anchor
+	for suffix := 1; used[unique]; suffix++ {
+		unique = fmt.Sprintf("%s-%d", anchor, suffix)
+	}
+	used[unique] = true
+	return unique
+}
+
+func tableOfContents(sections []markdownSection) []SectionEntry {
+	entries := make([]SectionEntry, 0, len(sections))
+	for _, section := range sections {
+		entries = append(entries, SectionEntry{
+			Number: section.Number,
+			Title:  section.Title,
+			Anchor: section.Anchor,
+			Level:  section.Level,
+			Bytes:  section.End - section.Start,
+		})
+	}
+	return entries
+}
+
+// preamble returns the text before the first heading.
+func preamble(body string, sections []markdownSection) string {
+	if len(sections) == 0 {
+		return body
+	}
+	return body[:sections[0].Start]
+}
+
+// selectSections resolves a comma-separated list of section numbers, anchors
+// or titles (case-insensitive) and returns the matching sections' text.
+func selectSections(body string, sections []markdownSection, selector string) ([]SectionEntry, string, error) {
+	selected := []SectionEntry{}
+	var builder strings.Builder
+	for _, wanted := range strings.Split(selector, ",") {
+		wanted = strings.TrimSpace(wanted)
+		if wanted == "" {
+			continue
+		}
+		index := findSection(sections, wanted)
+		if index < 0 {
+			return nil, "", SkillError{Code: "section_error", Message: fmt.Sprintf("unknown section '%s'", wanted)}
+		}
+		section := sections[index]
+		selected = append(selected, tableOfContents(sections[index:index+1])...)
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), "\n") {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(body[section.Start:section.End])
+	}
+	if len(selected) == 0 {
+		return nil, "", SkillError{Code: "section_error", Message: "the 'section' parameter did not name any section"}
+	}
+	return selected, builder.String(), nil
+}
+
+func findSection(sections []markdownSection, wanted string) int {
+	wanted = strings.TrimPrefix(wanted, "#")
+	for i, section := range sections {
+		if section.Number == wanted || section.Anchor == wanted {
+			return i
+		}
+	}
+	for i, section := range sections {
+		if strings.EqualFold(section.Title, wanted) {
+			return i
+		}
+	}
+	return -1
+}
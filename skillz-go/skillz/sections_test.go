@@ -0,0 +1,118 @@
+package skillz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMarkdownSectionsSkipsCodeFences(t *testing.T) {
+	body := "Intro\n# Setup\nText\n## Install\n```sh\n# not a heading\n```\n## Configure\nMore\n# Usage\nDone\n"
+	sections := parseMarkdownSections(body)
+
+	numbers := []string{}
+	for _, section := range sections {
+		numbers = append(numbers, section.Number+" "+section.Title)
+	}
+	expected := []string{"1 Setup", "1.1 Install", "1.2 Configure", "2 Usage"}
+	if strings.Join(numbers, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected sections: %v", numbers)
+	}
+	if got := body[sections[0].Start:sections[0].End]; !strings.HasSuffix(got, "More\n") {
+		t.Fatalf("expected Setup to include its subsections, got %q", got)
+	}
+	if preamble(body, sections) != "Intro\n" {
+		t.Fatalf("unexpected preamble: %q", preamble(body, sections))
+	}
+}
+
+func TestParseMarkdownSectionsNumbersSkippedLevels(t *testing.T) {
+	body := "# A\n### B\nb\n## C\nc\n# D\n"
+	sections := parseMarkdownSections(body)
+
+	numbers := []string{}
+	for _, section := range sections {
+		numbers = append(numbers, section.Number+" "+section.Title)
+	}
+	expected := []string{"1 A", "1.1 B", "1.2 C", "2 D"}
+	if strings.Join(numbers, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected sections: %v", numbers)
+	}
+	if _, text, err := selectSections(body, sections, "1.2"); err != nil || text != "## C\nc\n" {
+		t.Fatalf("expected 1.2 to select C, got %q %v", text, err)
+	}
+}
+
+func TestParseMarkdownSectionsDeduplicatesAnchors(t *testing.T) {
+	body := "# Setup\n## Usage\na\n# Deploy\n## Usage\nb\n## Usage 1\n## Usage\nc\n"
+	sections := parseMarkdownSections(body)
+
+	anchors := []string{}
+	for _, section := range sections {
+		anchors = append(anchors, section.Anchor)
+	}
+	expected := []string{"setup", "usage", "deploy", "usage-1", "usage-1-1", "usage-2"}
+	if strings.Join(anchors, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected anchors: %v", anchors)
+	}
+	if _, text, err := selectSections(body, sections, "usage-2"); err != nil || text != "## Usage\nc\n" {
+		t.Fatalf("expected usage-2 to select the third Usage, got %q %v", text, err)
+	}
+}
+
+func TestSelectSectionsByNumberAnchorAndTitle(t *testing.T) {
+	body := "# Setup\nA\n## Install\nB\n# Usage\nC\n"
+	sections := parseMarkdownSections(body)
+
+	selected, text, err := selectSections(body, sections, "1.1, usage")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(selected) != 2 || text != "## Install\nB\n# Usage\nC\n" {
+		t.Fatalf("unexpected selection: %v %q", selected, text)
+	}
+	if _, _, err := selectSections(body, sections, "missing"); err == nil {
+		t.Fatalf("expected unknown section error")
+	}
+}
+
+func TestSkillToolReturnsTableOfContentsForLongInstructions(t *testing.T) {
+	temp := t.TempDir()
+	dir := filepath.Join(temp, "long")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	filler := strings.Repeat("line of guidance\n", maxInlineInstructionBytes/16)
+	content := "---\nname: long\ndescription: Long skill\n---\nOverview\n# First\n" + filler + "# Second\nShort\n"
+	if err := os.WriteFile(filepath.Join(dir, SkillMarkdown), []byte(content), 0o644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{})
+
+	result := callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "long",
+		"arguments": map[string]any{"task": "do it"},
+	})
+	structured := result["structuredContent"].(map[string]any)
+	if structured["instructions"] != "Overview\n" {
+		t.Fatalf("expected preamble only, got %d bytes", len(structured["instructions"].(string)))
+	}
+	if toc := structured["table_of_contents"].([]any); len(toc) != 2 {
+		t.Fatalf("expected 2 toc entries, got %v", toc)
+	}
+
+	result = callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "long",
+		"arguments": map[string]any{"task": "do it", "section": "Second"},
+	})
+	structured = result["structuredContent"].(map[string]any)
+	if structured["instructions"] != "# Second\nShort\n" {
+		t.Fatalf("unexpected section: %q", structured["instructions"])
+	}
+}
@@ -0,0 +1,437 @@
+package skillz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is storage that skills are discovered in and read from. The Registry
+// scans Root and then each of its Sources in order; the first skill found for
+// a name wins. Candidates are opaque, source-defined locations that may hold
+// a skill, such as a directory or an archive path.
+type Source interface {
+	// Name identifies the source; it is recorded as Skill.Origin.
+	Name() string
+	// Candidates lists the locations that may hold a skill, in discovery order.
+	Candidates(ctx context.Context) ([]string, error)
+	// OpenSkillMarkdown opens the SKILL.md of a candidate.
+	OpenSkillMarkdown(candidate string) (io.ReadCloser, error)
+	// ListResources describes every resource of a candidate except SKILL.md,
+	// with slash-separated paths relative to the skill root.
+	ListResources(candidate string) ([]ResourceInfo, error)
+	// OpenResource opens one resource of a candidate and reports its size.
+	OpenResource(candidate string, relPath string) (io.ReadCloser, int64, error)
+	// Stat describes one resource. An empty relPath describes the candidate
+	// itself; a change of its ModTime invalidates lazily loaded content.
+	Stat(candidate string, relPath string) (ResourceInfo, error)
+}
+
+var (
+	errMissingSkillMarkdown = errors.New("no SKILL.md at the archive root or in a single top-level directory")
+	errInvalidArchive       = errors.New("invalid archive")
+)
+
+// isArchiveName reports whether a candidate names a skill archive rather than
+// a directory.
+func isArchiveName(name string) bool {
+	switch strings.ToLower(path.Ext(filepath.ToSlash(name))) {
+	case ".zip", ".skill":
+		return true
+	}
+	return isTarArchiveName(name)
+}
+
+// isTarArchiveName reports whether a candidate names a gzipped tar archive.
+func isTarArchiveName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// DirSource discovers skills in a local directory tree: every directory with
+// a SKILL.md, and every .zip, .skill, .tar.gz or .tgz archive. Candidates are
+// absolute paths.
+type DirSource struct {
+	fsSource
+}
+
+func NewDirSource(name string, root string) (*DirSource, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &DirSource{fsSource{name: name, fsys: os.DirFS(absRoot), root: absRoot}}, nil
+}
+
+// Root is the absolute directory the source scans.
+func (d *DirSource) Root() string {
+	return d.root
+}
+
+// ZipSource serves the single skill packaged in an archive: .zip, .skill,
+// .tar.gz or .tgz.
+type ZipSource struct {
+	fsSource
+}
+
+func NewZipSource(name string, archivePath string) (*ZipSource, error) {
+	absPath, err := filepath.Abs(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	if !isArchiveName(absPath) {
+		return nil, fmt.Errorf("%s is not a .zip, .skill, .tar.gz or .tgz archive", archivePath)
+	}
+	directory := filepath.Dir(absPath)
+	return &ZipSource{fsSource{name: name, fsys: os.DirFS(directory), root: directory, archive: absPath}}, nil
+}
+
+// FSSource discovers skills in an fs.FS, such as an embed.FS or
+// fstest.MapFS, with the same rules as DirSource. Candidates are
+// slash-separated paths within the file system.
+type FSSource struct {
+	fsSource
+}
+
+func NewFSSource(name string, fsys fs.FS) *FSSource {
+	return &FSSource{fsSource{name: name, fsys: fsys}}
+}
+
+// embeddedOrigin is the Origin of skills compiled into the binary.
+const embeddedOrigin = "embedded"
+
+// EmbeddedSource serves skills compiled into the binary with an embed.FS. Its
+// skills have Origin "embedded".
+type EmbeddedSource struct {
+	fsSource
+}
+
+// NewEmbeddedSource serves the skills under dir of fsys, usually the directory
+// named by the //go:embed directive.
+func NewEmbeddedSource(fsys fs.FS, dir string) (*EmbeddedSource, error) {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedSource{fsSource{name: embeddedOrigin, fsys: sub}}, nil
+}
+
+// fsSource implements Source over an fs.FS. When root is set the file system
+// mirrors that local directory and candidates are absolute local paths.
+type fsSource struct {
+	name string
+	fsys fs.FS
+	root string
+	// archive restricts the source to this single archive candidate.
+	archive string
+
+	mu         sync.Mutex
+	tarIndexes map[string]*tarArchive
+}
+
+func (s *fsSource) Name() string {
+	return s.name
+}
+
+// localRoot is the local directory behind the source, or "".
+func (s *fsSource) localRoot() string {
+	return s.root
+}
+
+func (s *fsSource) Candidates(ctx context.Context) ([]string, error) {
+	if s.archive != "" {
+		if _, err := os.Stat(s.archive); err != nil {
+			return nil, err
+		}
+		return []string{s.archive}, nil
+	}
+	stat, err := fs.Stat(s.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", s.candidate("."))
+	}
+	scan := &directoryScan{source: s, slots: make(chan struct{}, scanConcurrency(ctx)-1)}
+	return append([]string{}, scan.scan(ctx, ".")...), nil
+}
+
+type scanConcurrencyContextKey struct{}
+
+// withScanConcurrency bounds how many directories the Candidates of local
+// sources read at once. Without it, they scan serially.
+func withScanConcurrency(ctx context.Context, concurrency int) context.Context {
+	return context.WithValue(ctx, scanConcurrencyContextKey{}, concurrency)
+}
+
+func scanConcurrency(ctx context.Context) int {
+	if concurrency, ok := ctx.Value(scanConcurrencyContextKey{}).(int); ok && concurrency > 0 {
+		return concurrency
+	}
+	return 1
+}
+
+// directoryScan lists the candidates of a source, reading subdirectories on
+// extra goroutines while slots are free and inline otherwise. Results are
+// assembled in walk order, so they match a serial scan.
+type directoryScan struct {
+	source *fsSource
+	slots  chan struct{}
+}
+
+// scan walks directory in sorted order: a directory holding SKILL.md is a
+// skill and is not descended into; otherwise subdirectories are scanned
+// before the archives next to them.
+func (d *directoryScan) scan(ctx context.Context, directory string) []string {
+	s := d.source
+	ctx, span := startSpan(ctx, "skillz.scan_directory")
+	defer span.End()
+	span.SetAttribute("skillz.directory", s.candidate(directory))
+
+	if stat, err := fs.Stat(s.fsys, path.Join(directory, SkillMarkdown)); err == nil && !stat.IsDir() {
+		return []string{s.candidate(directory)}
+	}
+
+	entries, err := fs.ReadDir(s.fsys, directory)
+	if err != nil {
+		span.SetError(err)
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var subdirectories []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			subdirectories = append(subdirectories, path.Join(directory, entry.Name()))
+		}
+	}
+	found := make([][]string, len(subdirectories))
+	var wg sync.WaitGroup
+	for index, subdirectory := range subdirectories {
+		select {
+		case d.slots <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-d.slots }()
+				found[index] = d.scan(ctx, subdirectory)
+			}()
+		default:
+			found[index] = d.scan(ctx, subdirectory)
+		}
+	}
+	wg.Wait()
+	var candidates []string
+	for _, subdirectory := range found {
+		candidates = append(candidates, subdirectory...)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isArchiveName(entry.Name()) {
+			candidates = append(candidates, s.candidate(path.Join(directory, entry.Name())))
+		}
+	}
+	return candidates
+}
+
+// candidate converts a path within fsys to a candidate.
+func (s *fsSource) candidate(name string) string {
+	if s.root == "" {
+		return name
+	}
+	return filepath.Join(s.root, filepath.FromSlash(name))
+}
+
+// fsPath converts a candidate back to a path within fsys.
+func (s *fsSource) fsPath(candidate string) (string, error) {
+	name := candidate
+	if s.root != "" {
+		rel, err := filepath.Rel(s.root, candidate)
+		if err != nil {
+			return "", err
+		}
+		name = filepath.ToSlash(rel)
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("candidate %s is outside source %s: %w", candidate, s.name, fs.ErrNotExist)
+	}
+	return name, nil
+}
+
+func (s *fsSource) OpenSkillMarkdown(candidate string) (io.ReadCloser, error) {
+	name, err := s.fsPath(candidate)
+	if err != nil {
+		return nil, err
+	}
+	if isArchiveName(name) {
+		archive, err := s.openArchive(name)
+		if err != nil {
+			return nil, err
+		}
+		reader, _, err := archive.open(SkillMarkdown)
+		return reader, err
+	}
+	return s.fsys.Open(path.Join(name, SkillMarkdown))
+}
+
+func (s *fsSource) ListResources(candidate string) ([]ResourceInfo, error) {
+	name, err := s.fsPath(candidate)
+	if err != nil {
+		return nil, err
+	}
+	if isArchiveName(name) {
+		archive, err := s.openArchive(name)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+		return archive.resources(), nil
+	}
+
+	resources := []ResourceInfo{}
+	err = fs.WalkDir(s.fsys, name, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil || entry.IsDir() {
+			return nil
+		}
+		relPath := strings.TrimPrefix(current, name+"/")
+		if name == "." {
+			relPath = current
+		}
+		if relPath == SkillMarkdown {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		resources = append(resources, ResourceInfo{Path: relPath, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return resources, err
+}
+
+func (s *fsSource) OpenResource(candidate string, relPath string) (io.ReadCloser, int64, error) {
+	name, err := s.fsPath(candidate)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !fs.ValidPath(relPath) || relPath == "." {
+		return nil, 0, fs.ErrNotExist
+	}
+	if isArchiveName(name) {
+		archive, err := s.openArchive(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		return archive.open(relPath)
+	}
+
+	file, err := s.fsys.Open(path.Join(name, relPath))
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		_ = file.Close()
+		return nil, 0, fs.ErrNotExist
+	}
+	return file, stat.Size(), nil
+}
+
+func (s *fsSource) Stat(candidate string, relPath string) (ResourceInfo, error) {
+	name, err := s.fsPath(candidate)
+	if err != nil {
+		return ResourceInfo{}, err
+	}
+	if relPath == "" {
+		return s.statCandidate(name)
+	}
+	if !fs.ValidPath(relPath) {
+		return ResourceInfo{}, fs.ErrNotExist
+	}
+	if isArchiveName(name) {
+		archive, err := s.openArchive(name)
+		if err != nil {
+			return ResourceInfo{}, err
+		}
+		defer archive.Close()
+		return archive.stat(relPath)
+	}
+	stat, err := fs.Stat(s.fsys, path.Join(name, relPath))
+	if err != nil {
+		return ResourceInfo{}, err
+	}
+	return ResourceInfo{Path: relPath, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// statCandidate reports the archive for archive skills. For directory skills
+// it reports the size of SKILL.md and the newer modification time of SKILL.md
+// and the skill directory, so edits to SKILL.md and added or removed files are
+// noticed without walking the skill.
+func (s *fsSource) statCandidate(name string) (ResourceInfo, error) {
+	stat, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return ResourceInfo{}, err
+	}
+	if isArchiveName(name) {
+		return ResourceInfo{Size: stat.Size(), ModTime: stat.ModTime()}, nil
+	}
+	skillStat, err := fs.Stat(s.fsys, path.Join(name, SkillMarkdown))
+	if err != nil {
+		return ResourceInfo{}, err
+	}
+	info := ResourceInfo{Size: skillStat.Size(), ModTime: skillStat.ModTime()}
+	if stat.ModTime().After(info.ModTime) {
+		info.ModTime = stat.ModTime()
+	}
+	return info, nil
+}
+
+// skillModTime reports the modification time used to invalidate lazily loaded
+// content.
+func skillModTime(skill Skill) (time.Time, error) {
+	if skill.src == nil {
+		return time.Time{}, fs.ErrNotExist
+	}
+	info, err := skill.src.Stat(skill.candidate, "")
+	return info.ModTime, err
+}
+
+// directoryTimes records the modification time of every directory below the
+// root of a directory skill that holds a resource, so resources added to or
+// removed from nested directories are noticed without walking the skill.
+func directoryTimes(source Source, candidate string, resources []ResourceInfo) map[string]time.Time {
+	if isArchiveName(candidate) {
+		return nil
+	}
+	times := map[string]time.Time{}
+	for _, resource := range resources {
+		for dir := path.Dir(resource.Path); dir != "."; dir = path.Dir(dir) {
+			if _, ok := times[dir]; ok {
+				break
+			}
+			// A directory that cannot be stat'ed keeps a zero time, which
+			// never matches and so reloads the skill next time.
+			info, _ := source.Stat(candidate, dir)
+			times[dir] = info.ModTime
+		}
+	}
+	return times
+}
+
+func directoriesUnchanged(source Source, candidate string, times map[string]time.Time) bool {
+	for dir, modTime := range times {
+		info, err := source.Stat(candidate, dir)
+		if err != nil || !info.ModTime.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
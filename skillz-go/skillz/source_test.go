@@ -0,0 +1,118 @@
+package skillz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSSourceServesDirectoryAndArchiveSkills(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.skill")
+	createZipSkill(t, archive, "packed", withFile("notes.txt", "notes"))
+	bundle, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"tools/echo/SKILL.md":         {Data: []byte(skillMarkdown("echo", withBody("Body of echo\n")))},
+		"tools/echo/scripts/run.sh":   {Data: []byte("echo hi\n")},
+		"bundle.skill":                {Data: bundle},
+		"tools/not-a-skill/README.md": {Data: []byte("ignored")},
+	}
+	for _, lazy := range []bool{false, true} {
+		registry := NewRegistry("")
+		registry.Lazy = lazy
+		registry.Sources = []Source{NewFSSource("memory", fsys)}
+		if err := registry.Load(); err != nil {
+			t.Fatalf("lazy=%v load: %v", lazy, err)
+		}
+		if skills := registry.Skills(); len(skills) != 2 {
+			t.Fatalf("lazy=%v: expected 2 skills, got %d", lazy, len(skills))
+		}
+
+		echo, err := registry.Resolve("echo")
+		if err != nil {
+			t.Fatalf("lazy=%v resolve echo: %v", lazy, err)
+		}
+		if echo.Origin != "memory" || strings.TrimSpace(echo.Instructions) != "Body of echo" {
+			t.Fatalf("lazy=%v unexpected echo skill: %+v", lazy, echo)
+		}
+		if data, err := echo.OpenBytes("scripts/run.sh"); err != nil || string(data) != "echo hi\n" {
+			t.Fatalf("lazy=%v read script: %q %v", lazy, data, err)
+		}
+
+		packed, err := registry.Resolve("packed")
+		if err != nil {
+			t.Fatalf("lazy=%v resolve packed: %v", lazy, err)
+		}
+		if !packed.IsZip() || !packed.HasResource("notes.txt") || packed.HasResource(SkillMarkdown) {
+			t.Fatalf("lazy=%v unexpected packed skill: %+v", lazy, packed)
+		}
+		if data, err := packed.OpenBytes("notes.txt"); err != nil || string(data) != "notes" {
+			t.Fatalf("lazy=%v read archive member: %q %v", lazy, data, err)
+		}
+	}
+}
+
+func TestRootShadowsLaterSources(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	archive := filepath.Join(t.TempDir(), "extra.zip")
+	createZipSkill(t, archive, "echo")
+	zipSource, err := NewZipSource("extra", archive)
+	if err != nil {
+		t.Fatalf("zip source: %v", err)
+	}
+	memory := NewFSSource("memory", fstest.MapFS{"other/SKILL.md": {Data: []byte(skillMarkdown("other"))}})
+
+	registry := NewRegistry(temp)
+	registry.Sources = []Source{zipSource, memory}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	echo, err := registry.Get("echo")
+	if err != nil || !echo.IsLocal() || echo.IsZip() {
+		t.Fatalf("expected local echo to win: %+v %v", echo, err)
+	}
+	other, err := registry.Get("other")
+	if err != nil || other.Origin != "memory" {
+		t.Fatalf("expected other from memory source: %+v %v", other, err)
+	}
+}
+
+func TestZipSourceRequiresArchiveExtension(t *testing.T) {
+	if _, err := NewZipSource("bad", filepath.Join(t.TempDir(), "skill.txt")); err == nil {
+		t.Fatalf("expected error for non-archive path")
+	}
+}
+
+func TestEmbeddedSourceMarksSkillsEmbedded(t *testing.T) {
+	fsys := fstest.MapFS{
+		"skills/echo/SKILL.md": {Data: []byte(skillMarkdown("echo"))},
+		"other/SKILL.md":       {Data: []byte(skillMarkdown("ignored"))},
+	}
+	embedded, err := NewEmbeddedSource(fsys, "skills")
+	if err != nil {
+		t.Fatalf("embedded source: %v", err)
+	}
+	temp := t.TempDir()
+	writeSkill(t, temp, "local")
+
+	registry := NewRegistry(temp)
+	registry.Sources = []Source{embedded}
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if skills := registry.Skills(); len(skills) != 2 {
+		t.Fatalf("expected local and embedded skills, got %d", len(skills))
+	}
+	echo, err := registry.Get("echo")
+	if err != nil || !echo.Embedded() {
+		t.Fatalf("expected embedded echo: %+v %v", echo, err)
+	}
+	if local, _ := registry.Get("local"); local.Embedded() {
+		t.Fatalf("local skill marked embedded")
+	}
+}
@@ -0,0 +1,237 @@
+package skillz
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// localOrigin is the Origin of skills discovered under Registry.Root.
+const localOrigin = "local"
+
+// RemoteSource is a Source that mirrors skills from somewhere other than
+// local disk into a local cache directory. It must keep serving the last
+// successfully synced content when Sync fails.
+type RemoteSource interface {
+	Source
+	// Dir is the local cache directory holding the synced skills.
+	Dir() string
+	// Sync refreshes the cache, reporting whether its contents changed.
+	Sync(ctx context.Context) (bool, error)
+}
+
+// revisionedSource is implemented by sources that can name the version of
+// their content a candidate was read from, recorded on each Skill as
+// Revision.
+type revisionedSource interface {
+	candidateRevision(candidate string) string
+}
+
+// prunableSource is implemented by remote sources that keep content a sync
+// superseded until the registry has reloaded and no longer serves it.
+type prunableSource interface {
+	prune() error
+}
+
+// pruneSources removes content that the sources of registry superseded. Load
+// calls it once the new snapshot is in place.
+func (r *Registry) pruneSources() {
+	for _, candidate := range r.Sources {
+		source, ok := candidate.(prunableSource)
+		if !ok {
+			continue
+		}
+		if err := source.prune(); err != nil {
+			r.logger().Warn("failed to prune source cache",
+				slog.String("source", candidate.Name()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// cacheLeaseGrace is how long content no process uses any more stays in a
+// shared cache, so reads that started just before a reload can finish.
+const cacheLeaseGrace = 5 * time.Minute
+
+// sharedCache coordinates the processes sharing the cache directory of a
+// remote source, such as the stdio servers started for each agent session.
+// Syncs and pruning hold the exclusive cache lock. Each process holds a
+// shared lease on every cache entry its registry may still read, and an
+// entry is pruned only once no process holds its lease and the last one was
+// released longer than grace ago.
+type sharedCache struct {
+	dir   string
+	grace time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*os.File
+}
+
+func newSharedCache(dir string) *sharedCache {
+	return &sharedCache{dir: dir, grace: cacheLeaseGrace, leases: map[string]*os.File{}}
+}
+
+// lock blocks until this process holds the cache lock and returns the
+// function releasing it.
+func (c *sharedCache) lock() (func(), error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(c.dir, "lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file, true); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() { _ = file.Close() }, nil
+}
+
+func (c *sharedCache) leasePath(name string) string {
+	return filepath.Join(c.dir, "leases", name)
+}
+
+// acquire leases the named entries. Callers hold the cache lock.
+func (c *sharedCache) acquire(names ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		if c.leases[name] != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(c.leasePath(name)), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(c.leasePath(name), os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		if err := lockFile(file, false); err != nil {
+			_ = file.Close()
+			return err
+		}
+		c.leases[name] = file
+	}
+	return nil
+}
+
+// releaseExcept releases the leases on every entry but current, recording
+// when each was released.
+func (c *sharedCache) releaseExcept(current map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for name, file := range c.leases {
+		if current[name] {
+			continue
+		}
+		_ = os.Chtimes(file.Name(), now, now)
+		_ = file.Close()
+		delete(c.leases, name)
+	}
+}
+
+// prune removes the entries of dir other than current that no process
+// leases and that were released longer than grace ago. An entry without a
+// lease file, such as one left by a crashed sync, gets one and is pruned
+// once the grace period has passed. Callers hold the cache lock.
+func (c *sharedCache) prune(dir string, current map[string]bool, remove func(name string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if current[entry.Name()] {
+			continue
+		}
+		errs = append(errs, c.pruneEntry(entry.Name(), remove))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *sharedCache) pruneEntry(name string, remove func(name string) error) error {
+	if err := os.MkdirAll(filepath.Dir(c.leasePath(name)), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(c.leasePath(name), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	locked, err := tryLockFile(file)
+	if err != nil || !locked {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil || time.Since(stat.ModTime()) < c.grace {
+		return err
+	}
+	if err := remove(name); err != nil {
+		return err
+	}
+	return os.Remove(file.Name())
+}
+
+// SyncSources syncs every source of registry. A failing source keeps its last
+// good cache; its error is logged and joined into the returned error.
+func SyncSources(ctx context.Context, registry *Registry) (bool, error) {
+	changed := false
+	var errs []error
+	for _, candidate := range registry.Sources {
+		source, ok := candidate.(RemoteSource)
+		if !ok {
+			continue
+		}
+		sourceChanged, err := source.Sync(ctx)
+		if err != nil {
+			registry.logger().Warn("source sync failed, serving cached skills",
+				slog.String("source", source.Name()),
+				slog.String("error", err.Error()),
+			)
+			errs = append(errs, err)
+			continue
+		}
+		if sourceChanged {
+			registry.logger().Info("source updated", slog.String("source", source.Name()))
+		}
+		changed = changed || sourceChanged
+	}
+	return changed, errors.Join(errs...)
+}
+
+// WatchSources syncs registry's sources every interval until ctx is done. When
+// any source changes the registry is reloaded and onReload is called with the
+// skills that were loaded before.
+func WatchSources(ctx context.Context, registry *Registry, interval time.Duration, onReload func(previous []Skill)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, _ := SyncSources(ctx, registry)
+		if !changed {
+			continue
+		}
+		previous := registry.Skills()
+		if err := registry.Load(); err != nil {
+			continue
+		}
+		if onReload != nil {
+			onReload(previous)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package skillz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mutual TLS: clients must present a certificate
+	// signed by one of these CAs.
+	ClientCAFile string
+}
+
+// Enabled reports whether any TLS option is set. The HTTP transports then
+// refuse to start without both a certificate and a key, so a client CA alone
+// never falls back to plaintext.
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || o.ClientCAFile != ""
+}
+
+// tlsNextProtos advertises HTTP/2 as http.Server.ServeTLS does for a static
+// configuration.
+var tlsNextProtos = []string{"h2", "http/1.1"}
+
+// certificateReloader serves the current certificate and client CA pool and
+// swaps them in place when reload succeeds, so existing listeners pick up
+// renewed certificates without a restart.
+type certificateReloader struct {
+	options     TLSOptions
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+func newCertificateReloader(options TLSOptions) (*certificateReloader, error) {
+	if options.CertFile == "" || options.KeyFile == "" {
+		if options.ClientCAFile != "" {
+			return nil, errors.New("a client CA requires a TLS certificate and key")
+		}
+		return nil, errors.New("both a TLS certificate and key must be provided")
+	}
+	reloader := &certificateReloader{options: options}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (r *certificateReloader) reload() error {
+	certificate, err := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS key pair: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.options.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.options.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.options.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate = &certificate
+	r.clientCAs = clientCAs
+	return nil
+}
+
+func (r *certificateReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: tlsNextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   tlsNextProtos,
+				Certificates: []tls.Certificate{*r.certificate},
+			}
+			if r.clientCAs != nil {
+				config.ClientCAs = r.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+// reloadOnSIGHUP reloads certificates whenever the process receives SIGHUP
+// until ctx is done. A failed reload keeps the previous certificates.
+func (r *certificateReloader) reloadOnSIGHUP(ctx context.Context, logger *slog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := r.reload(); err != nil {
+					logger.Error("TLS reload failed, keeping previous certificates", slog.String("error", err.Error()))
+				} else {
+					logger.Info("TLS certificates reloaded")
+				}
+			}
+		}
+	}()
+}
+
+// ClientCertificateAuthenticator identifies mutual TLS clients by the common
+// name of their verified certificate, with organizational units as groups.
+type ClientCertificateAuthenticator struct{}
+
+func (ClientCertificateAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, errNoCredentials
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	subject := certificate.Subject
+	if subject.CommonName == "" {
+		return Identity{}, errNoCredentials
+	}
+	return Identity{
+		Subject:    subject.CommonName,
+		Groups:     subject.OrganizationalUnit,
+		Method:     "mtls",
+		Credential: credentialFingerprint(certificate.Raw),
+	}, nil
+}
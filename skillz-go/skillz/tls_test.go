@@ -0,0 +1,179 @@
+package skillz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	der         []byte
+}
+
+func issueCertificate(t *testing.T, commonName string, parent *testCertificate, isCA bool) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, OrganizationalUnit: []string{"agents"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return &testCertificate{certificate: certificate, key: key, der: der}
+}
+
+func (c *testCertificate) writeFiles(t *testing.T, dir string, name string) (string, string) {
+	t.Helper()
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestMutualTLSWithReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, "test-ca", nil, true)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	certFile, keyFile := issueCertificate(t, "server-1", ca, false).writeFiles(t, dir, "server")
+	client := issueCertificate(t, "agent-a", ca, false)
+
+	reloader, err := newCertificateReloader(TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.tlsConfig())
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	handler := requireAuthentication(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := IdentityFromContext(r.Context())
+		_, _ = w.Write([]byte(identity.Subject))
+	}), []Authenticator{ClientCertificateAuthenticator{}})
+	httpServer := &http.Server{Handler: handler}
+	go func() { _ = httpServer.Serve(listener) }()
+	defer httpServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	newClient := func(certificates ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates},
+			DisableKeepAlives: true,
+		}}
+	}
+	url := "https://" + listener.Addr().String() + "/mcp"
+
+	response, err := newClient(client.tlsCertificate()).Get(url)
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if string(body) != "agent-a" || response.TLS.PeerCertificates[0].Subject.CommonName != "server-1" {
+		t.Fatalf("unexpected response %q from %s", body, response.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+
+	if response, err := newClient().Get(url); err == nil {
+		_ = response.Body.Close()
+		t.Fatalf("expected request without client certificate to fail")
+	}
+
+	issueCertificate(t, "server-2", ca, false).writeFiles(t, dir, "server")
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	response, err = newClient(client.tlsCertificate()).Get(url)
+	if err != nil {
+		t.Fatalf("request after reload: %v", err)
+	}
+	_ = response.Body.Close()
+	if name := response.TLS.PeerCertificates[0].Subject.CommonName; name != "server-2" {
+		t.Fatalf("expected reloaded certificate, got %s", name)
+	}
+}
+
+func TestCertificateReloaderRequiresKeyPair(t *testing.T) {
+	if _, err := newCertificateReloader(TLSOptions{CertFile: "server.crt"}); err == nil {
+		t.Fatalf("expected missing key error")
+	}
+	clientCAOnly := TLSOptions{ClientCAFile: "ca.crt"}
+	if !clientCAOnly.Enabled() {
+		t.Fatalf("expected a client CA to enable TLS")
+	}
+	err := RunMCPServer(context.Background(), BuildMCPServer(NewRegistry(t.TempDir()), ServerOptions{}), RunOptions{
+		Transport: "http",
+		Host:      "127.0.0.1",
+		Path:      "/mcp",
+		TLS:       clientCAOnly,
+	})
+	if err == nil || !strings.Contains(err.Error(), "client CA requires") {
+		t.Fatalf("expected a client CA without a key pair to fail at startup, got %v", err)
+	}
+}
+
+func TestTLSConfigForClientNegotiatesHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := issueCertificate(t, "server", nil, false).writeFiles(t, dir, "server")
+	reloader, err := newCertificateReloader(TLSOptions{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	config, err := reloader.tlsConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("config for client: %v", err)
+	}
+	if !slices.Equal(config.NextProtos, []string{"h2", "http/1.1"}) {
+		t.Fatalf("unexpected NextProtos %v", config.NextProtos)
+	}
+}
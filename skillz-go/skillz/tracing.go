@@ -0,0 +1,406 @@
+package skillz
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultTraceBatchSize     = 512
+	defaultTraceFlushInterval = 5 * time.Second
+	traceparentHeader         = "traceparent"
+)
+
+// OTLP span kinds and status codes.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	statusCodeError  = 2
+)
+
+type TracerOptions struct {
+	// Endpoint is the OTLP/HTTP collector, e.g. http://localhost:4318. Spans
+	// are posted as JSON to /v1/traces unless the endpoint already has a path.
+	Endpoint    string
+	ServiceName string
+	// Headers are added to every export request, e.g. for collector auth.
+	Headers       map[string]string
+	BatchSize     int
+	FlushInterval time.Duration
+	Client        *http.Client
+}
+
+// Tracer records spans and exports them in batches to an OTLP/HTTP collector
+// using the JSON encoding, so tracing needs no SDK dependency. A nil *Tracer
+// is valid and records nothing.
+type Tracer struct {
+	options  TracerOptions
+	endpoint string
+
+	mu      sync.Mutex
+	pending []*Span
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+func (c spanContext) valid() bool {
+	return c.traceID != [16]byte{} && c.spanID != [8]byte{}
+}
+
+// Span is a timed operation. Its methods are safe to call on a nil span.
+type Span struct {
+	tracer     *Tracer
+	name       string
+	kind       int
+	context    spanContext
+	parentID   [8]byte
+	start      time.Time
+	end        time.Time
+	attributes map[string]any
+	errMessage string
+	mu         sync.Mutex
+}
+
+type spanContextKey struct{}
+
+type tracerContextKey struct{}
+
+func NewTracer(options TracerOptions) (*Tracer, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(options.Endpoint), "/")
+	if endpoint == "" {
+		return nil, fmt.Errorf("tracing requires an OTLP endpoint")
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("OTLP endpoint %q must be an http or https URL", options.Endpoint)
+	}
+	if strings.Count(endpoint, "/") == 2 {
+		endpoint += "/v1/traces"
+	}
+	if options.ServiceName == "" {
+		options.ServiceName = "skillz"
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultTraceBatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultTraceFlushInterval
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	tracer := &Tracer{
+		options:  options,
+		endpoint: endpoint,
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go tracer.run()
+	return tracer, nil
+}
+
+// Start begins a span that is a child of the span in ctx, if any.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	return t.start(ctx, name, spanKindInternal)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent, _ := ctx.Value(spanContextKey{}).(spanContext)
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attributes: map[string]any{}}
+	if parent.valid() {
+		span.context.traceID = parent.traceID
+		span.context.sampled = parent.sampled
+		span.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.context.traceID[:])
+		span.context.sampled = true
+	}
+	_, _ = rand.Read(span.context.spanID[:])
+	ctx = context.WithValue(ctx, tracerContextKey{}, t)
+	return context.WithValue(ctx, spanContextKey{}, span.context), span
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, *Span) {
+	tracer, _ := ctx.Value(tracerContextKey{}).(*Tracer)
+	return tracer.Start(ctx, name)
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed; a nil err is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = err.Error()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.context.sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= t.options.BatchSize
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.flush:
+		}
+		_ = t.Flush(context.Background())
+	}
+}
+
+// Flush exports every ended span that has not been exported yet.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.exportRequest(spans))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range t.options.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := t.options.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("export spans: collector returned %s", response.Status)
+	}
+	return nil
+}
+
+// Shutdown stops the background exporter and flushes remaining spans.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	select {
+	case <-t.done:
+	default:
+		close(t.done)
+	}
+	<-t.stopped
+	return t.Flush(ctx)
+}
+
+func (t *Tracer) exportRequest(spans []*Span) map[string]any {
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, span.otlp())
+	}
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": t.options.ServiceName}),
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "skillz", "version": serverVersion},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+func (s *Span) otlp() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoded := map[string]any{
+		"traceId":           hex.EncodeToString(s.context.traceID[:]),
+		"spanId":            hex.EncodeToString(s.context.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attributes),
+	}
+	if s.parentID != [8]byte{} {
+		encoded["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if s.errMessage != "" {
+		encoded["status"] = map[string]any{"code": statusCodeError, "message": s.errMessage}
+	}
+	return encoded
+}
+
+func otlpAttributes(attributes map[string]any) []map[string]any {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	encoded := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		var value map[string]any
+		switch typed := attributes[key].(type) {
+		case bool:
+			value = map[string]any{"boolValue": typed}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(typed)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(typed, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": typed}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(typed)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": value})
+	}
+	return encoded
+}
+
+func parseTraceparent(header string) (spanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return spanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return spanContext{}, false
+	}
+	var parsed spanContext
+	if _, err := hex.Decode(parsed.traceID[:], []byte(parts[1])); err != nil {
+		return spanContext{}, false
+	}
+	if _, err := hex.Decode(parsed.spanID[:], []byte(parts[2])); err != nil {
+		return spanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || !parsed.valid() {
+		return spanContext{}, false
+	}
+	parsed.sampled = flags[0]&1 == 1
+	return parsed, true
+}
+
+func propagateTraceContext(next http.Handler, tracer *Tracer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if remote, ok := parseTraceparent(r.Header.Get(traceparentHeader)); ok {
+			ctx = context.WithValue(ctx, spanContextKey{}, remote)
+		}
+		ctx, span := tracer.start(ctx, "HTTP "+r.Method, spanKindServer)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (t *Tracer) serverOptions(registry *Registry, options ServerOptions) []server.ServerOption {
+	toolMiddleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := t.Start(ctx, "tools/call "+request.Params.Name)
+			defer span.End()
+			span.SetAttribute("mcp.tool", request.Params.Name)
+			result, err := next(ctx, request)
+			call := options.classifyToolCall(registry, request)
+			if call.skill != "" {
+				span.SetAttribute("skillz.skill", call.skill)
+			}
+			if call.kind == fetchResourceCall {
+				outcome, bytes := fetchResultSummary(result, err)
+				span.SetAttribute("skillz.resource_uri", call.uri)
+				span.SetAttribute("skillz.bytes", bytes)
+				span.SetAttribute("skillz.outcome", outcome)
+				if outcome != "ok" {
+					span.SetError(fmt.Errorf("%s", outcome))
+				}
+			}
+			span.SetError(err)
+			if err == nil && result != nil && result.IsError {
+				span.SetError(fmt.Errorf("tool returned an error result"))
+			}
+			return result, err
+		}
+	}
+	resourceMiddleware := func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			ctx, span := t.Start(ctx, "resources/read")
+			defer span.End()
+			read := classifyResourceRead(registry, request.Params.URI)
+			span.SetAttribute("skillz.resource_uri", read.uri)
+			span.SetAttribute("skillz.skill", read.skill)
+			contents, err := next(ctx, request)
+			span.SetAttribute("skillz.bytes", resourceContentBytes(contents))
+			span.SetError(err)
+			return contents, err
+		}
+	}
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(toolMiddleware),
+		server.WithResourceHandlerMiddleware(resourceMiddleware),
+	}
+}
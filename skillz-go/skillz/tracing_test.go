@@ -0,0 +1,207 @@
+package skillz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type collectedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+func (s collectedSpan) attribute(key string) any {
+	for _, attribute := range s.Attributes {
+		if attribute.Key == key {
+			for _, value := range attribute.Value {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// newCollector stands in for an OTLP/HTTP collector and returns every span it
+// has received.
+func newCollector(t *testing.T) (*httptest.Server, func() []collectedSpan) {
+	t.Helper()
+	var mu sync.Mutex
+	var spans []collectedSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []collectedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resourceSpans := range payload.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				spans = append(spans, scopeSpans.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(collector.Close)
+	return collector, func() []collectedSpan {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]collectedSpan(nil), spans...)
+	}
+}
+
+func spansByName(spans []collectedSpan) map[string][]collectedSpan {
+	named := map[string][]collectedSpan{}
+	for _, span := range spans {
+		named[span.Name] = append(named[span.Name], span)
+	}
+	return named
+}
+
+func TestRegistryLoadExportsNestedSpans(t *testing.T) {
+	collector, collected := newCollector(t)
+	tracer, err := NewTracer(TracerOptions{Endpoint: collector.URL})
+	if err != nil {
+		t.Fatalf("new tracer: %v", err)
+	}
+
+	temp := t.TempDir()
+	writeSkill(t, temp, "plain")
+	createZipSkill(t, filepath.Join(temp, "zipped.zip"), "zipped")
+	registry := NewRegistry(temp)
+	registry.Tracer = tracer
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	named := spansByName(collected())
+	if len(named["skillz.registry.load"]) != 1 || len(named["skillz.scan_archive"]) != 1 || len(named["skillz.scan_directory"]) < 2 {
+		t.Fatalf("unexpected spans: %v", named)
+	}
+	load := named["skillz.registry.load"][0]
+	if load.ParentSpanID != "" || load.attribute("skillz.skills") != "2" {
+		t.Fatalf("unexpected load span: %+v", load)
+	}
+	rootScan := named["skillz.scan_directory"][0]
+	for _, span := range named["skillz.scan_directory"] {
+		if span.ParentSpanID == load.SpanID {
+			rootScan = span
+		}
+	}
+	if rootScan.ParentSpanID != load.SpanID {
+		t.Fatalf("root scan is not a child of load: %+v", named["skillz.scan_directory"])
+	}
+	if archive := named["skillz.scan_archive"][0]; archive.ParentSpanID != load.SpanID || archive.TraceID != load.TraceID {
+		t.Fatalf("archive span not nested under load: %+v", archive)
+	}
+}
+
+func TestStreamableHTTPContinuesIncomingTrace(t *testing.T) {
+	collector, collected := newCollector(t)
+	tracer, err := NewTracer(TracerOptions{Endpoint: collector.URL})
+	if err != nil {
+		t.Fatalf("new tracer: %v", err)
+	}
+
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{Tracer: tracer})
+	httpServer := httptest.NewServer(propagateTraceContext(server.NewStreamableHTTPServer(mcpServer), tracer))
+	defer httpServer.Close()
+
+	post := func(sessionID string, body string) *http.Response {
+		request, _ := http.NewRequest(http.MethodPost, httpServer.URL+"/mcp", bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/json, text/event-stream")
+		request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		if sessionID != "" {
+			request.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		return response
+	}
+	response := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	_ = response.Body.Close()
+	sessionID := response.Header.Get("Mcp-Session-Id")
+	response = post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fetch_resource","arguments":{"resource_uri":"resource://skillz/testskill/script.py"}}}`)
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("tools/call status: %d", response.StatusCode)
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	named := spansByName(collected())
+	calls := named["tools/call fetch_resource"]
+	if len(calls) != 1 {
+		t.Fatalf("expected one tool span, got %v", named)
+	}
+	call := calls[0]
+	if call.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || call.attribute("skillz.skill") != "testskill" || call.attribute("skillz.bytes") != "14" {
+		t.Fatalf("unexpected tool span: %+v", call)
+	}
+	var parent collectedSpan
+	for _, span := range named["HTTP POST"] {
+		if span.SpanID == call.ParentSpanID {
+			parent = span
+		}
+	}
+	if parent.ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("HTTP span does not continue the incoming trace: %+v", named["HTTP POST"])
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	parsed, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if !ok || parsed.sampled {
+		t.Fatalf("expected unsampled context, got %+v %v", parsed, ok)
+	}
+	for _, header := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		if _, ok := parseTraceparent(header); ok {
+			t.Fatalf("expected %q to be rejected", header)
+		}
+	}
+}
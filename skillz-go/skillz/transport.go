@@ -0,0 +1,231 @@
+package skillz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultDrainTimeout bounds how long in-flight requests may run after a
+// shutdown signal.
+const defaultDrainTimeout = 10 * time.Second
+
+type RunOptions struct {
+	Transport string
+	Host      string
+	Port      int
+	Path      string
+	// Listen overrides Host and Port, e.g. "unix:///run/skillz.sock".
+	Listen string
+	// SocketMode sets the permissions of a Unix socket; zero means 0600.
+	SocketMode os.FileMode
+	// Authenticators guard the http and sse transports; a request is accepted
+	// when any of them recognises its credentials.
+	Authenticators []Authenticator
+	TLS            TLSOptions
+	// DrainTimeout bounds graceful shutdown once ctx is cancelled; zero means
+	// defaultDrainTimeout.
+	DrainTimeout time.Duration
+	// Registry, when set, backs the /readyz endpoint of the http and sse
+	// transports.
+	Registry *Registry
+	// Metrics, when set, is served at /metrics behind the same authentication
+	// as the MCP endpoint.
+	Metrics http.Handler
+	// Tracer, when set, continues traces from incoming traceparent headers on
+	// the http and sse transports.
+	Tracer *Tracer
+	// Logger, when set, receives lifecycle events such as the listening
+	// address, shutdown and certificate reload failures.
+	Logger *slog.Logger
+}
+
+// RunMCPServer serves mcpServer until ctx is cancelled, then stops accepting
+// requests and waits up to DrainTimeout for in-flight ones to finish.
+func RunMCPServer(ctx context.Context, mcpServer *server.MCPServer, options RunOptions) error {
+	transport := strings.ToLower(strings.TrimSpace(options.Transport))
+	if transport == "" {
+		transport = "stdio"
+	}
+
+	switch transport {
+	case "stdio":
+		stdioServer := server.NewStdioServer(mcpServer)
+		stdioServer.SetContextFunc(func(ctx context.Context) context.Context {
+			return withTransport(ctx, "stdio")
+		})
+		done := make(chan error, 1)
+		go func() { done <- stdioServer.Listen(ctx, os.Stdin, os.Stdout) }()
+		select {
+		case err := <-done:
+			return ignoreCancellation(err)
+		case <-ctx.Done():
+		}
+		select {
+		case err := <-done:
+			return ignoreCancellation(err)
+		case <-time.After(options.drainTimeout()):
+			return errors.New("timed out draining in-flight stdio requests")
+		}
+	case "http":
+		httpServer := &http.Server{}
+		streamingServer := server.NewStreamableHTTPServer(
+			mcpServer,
+			server.WithEndpointPath(options.Path),
+			server.WithStreamableHTTPServer(httpServer),
+		)
+		return serveHTTP(ctx, httpServer, options.Path, withTransportName(streamingServer, "http"), streamingServer.Shutdown, options)
+	case "sse":
+		httpServer := &http.Server{}
+		sseServer := server.NewSSEServer(
+			mcpServer,
+			server.WithBasePath(options.Path),
+			server.WithHTTPServer(httpServer),
+		)
+		return serveHTTP(ctx, httpServer, "/", withTransportName(sseServer, "sse"), sseServer.Shutdown, options)
+	default:
+		return fmt.Errorf("unsupported transport: %s", options.Transport)
+	}
+}
+
+type transportContextKey struct{}
+
+func withTransport(ctx context.Context, transport string) context.Context {
+	return context.WithValue(ctx, transportContextKey{}, transport)
+}
+
+// transportFromContext names the transport that delivered the request, or ""
+// outside RunMCPServer.
+func transportFromContext(ctx context.Context) string {
+	transport, _ := ctx.Value(transportContextKey{}).(string)
+	return transport
+}
+
+func withTransportName(next http.Handler, transport string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withTransport(r.Context(), transport)))
+	})
+}
+
+func (o RunOptions) drainTimeout() time.Duration {
+	if o.DrainTimeout > 0 {
+		return o.DrainTimeout
+	}
+	return defaultDrainTimeout
+}
+
+func ignoreCancellation(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// serveHTTP mounts the MCP handler behind authentication next to the
+// unauthenticated health endpoints and serves until ctx is cancelled. shutdown
+// closes the transport's sessions and the underlying http.Server.
+func serveHTTP(ctx context.Context, httpServer *http.Server, pattern string, handler http.Handler, shutdown func(context.Context) error, options RunOptions) error {
+	var draining atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, map[string]any{"status": "ok"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status, body := readiness(options.Registry, draining.Load())
+		writeHealth(w, status, body)
+	})
+	if options.Metrics != nil {
+		mux.Handle("/metrics", requireAuthentication(options.Metrics, options.Authenticators))
+	}
+	if options.Tracer != nil {
+		handler = propagateTraceContext(handler, options.Tracer)
+	}
+	mux.Handle(pattern, requireAuthentication(handler, options.Authenticators))
+	httpServer.Handler = mux
+
+	var reloader *certificateReloader
+	if options.TLS.Enabled() {
+		var err error
+		reloader, err = newCertificateReloader(options.TLS)
+		if err != nil {
+			return err
+		}
+		httpServer.TLSConfig = reloader.tlsConfig()
+	}
+
+	listener, err := listen(options)
+	if err != nil {
+		return err
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if reloader == nil {
+			served <- httpServer.Serve(listener)
+			return
+		}
+		reloader.reloadOnSIGHUP(ctx, options.logger())
+		served <- httpServer.ServeTLS(listener, "", "")
+	}()
+
+	options.logger().Info("serving", slog.String("address", listener.Addr().String()), slog.String("path", pattern), slog.Bool("tls", reloader != nil))
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	draining.Store(true)
+	options.logger().Info("shutting down", slog.Duration("drain_timeout", options.drainTimeout()))
+	drainCtx, cancel := context.WithTimeout(context.Background(), options.drainTimeout())
+	defer cancel()
+	if err := shutdown(drainCtx); err != nil {
+		_ = httpServer.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (o RunOptions) logger() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger()
+	}
+	return o.Logger
+}
+
+func readiness(registry *Registry, draining bool) (int, map[string]any) {
+	body := map[string]any{"status": "ready"}
+	if registry != nil {
+		registryStatus := registry.Status()
+		body["registry"] = registryStatus
+		if !registryStatus.Loaded {
+			body["status"] = "not_ready"
+			return http.StatusServiceUnavailable, body
+		}
+	}
+	if draining {
+		body["status"] = "draining"
+		return http.StatusServiceUnavailable, body
+	}
+	return http.StatusOK, body
+}
+
+func writeHealth(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,107 @@
+package skillz
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func unixClient(socketPath string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+}
+
+func TestHTTPTransportHealthAndGracefulShutdown(t *testing.T) {
+	temp := t.TempDir()
+	writeSkill(t, temp, "echo")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	tokens, err := ParseStaticTokens("ops=secret")
+	if err != nil {
+		t.Fatalf("parse tokens: %v", err)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "skillz.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunMCPServer(ctx, BuildMCPServer(registry, ServerOptions{}), RunOptions{
+			Transport:      "http",
+			Path:           "/mcp",
+			Listen:         "unix://" + socketPath,
+			Authenticators: []Authenticator{tokens},
+			Registry:       registry,
+			DrainTimeout:   time.Second,
+		})
+	}()
+
+	client := unixClient(socketPath)
+	var response *http.Response
+	for attempt := 0; attempt < 50; attempt++ {
+		if response, err = client.Get("http://skillz/healthz"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("healthz: %v", err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected healthz status: %d", response.StatusCode)
+	}
+
+	response, err = client.Get("http://skillz/readyz")
+	if err != nil {
+		t.Fatalf("readyz: %v", err)
+	}
+	body := map[string]any{}
+	_ = json.NewDecoder(response.Body).Decode(&body)
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK || body["registry"].(map[string]any)["skills"] != float64(1) {
+		t.Fatalf("unexpected readyz: %d %v", response.StatusCode, body)
+	}
+
+	response, err = client.Post("http://skillz/mcp", "application/json", nil)
+	if err != nil {
+		t.Fatalf("mcp: %v", err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected health endpoints only to bypass auth, got %d", response.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server did not shut down")
+	}
+}
+
+func TestReadinessReportsUnloadedRegistry(t *testing.T) {
+	registry := NewRegistry(filepath.Join(t.TempDir(), "missing"))
+	_ = registry.Load()
+
+	status, body := readiness(registry, false)
+	if status != http.StatusServiceUnavailable || body["registry"].(RegistryStatus).Error == "" {
+		t.Fatalf("expected unavailable with error, got %d %v", status, body)
+	}
+
+	status, _ = readiness(nil, true)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("expected draining server to be unready, got %d", status)
+	}
+}
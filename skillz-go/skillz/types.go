@@ -0,0 +1,71 @@
+package skillz
+
+import (
+	"path/filepath"
+	"time"
+)
+
+const SkillMarkdown = "SKILL.md"
+
+type SkillMetadata struct {
+	Name         string
+	Description  string
+	License      string
+	AllowedTools []string
+	Extra        map[string]any
+}
+
+type Skill struct {
+	Slug         string
+	Directory    string
+	Instructions string
+	Metadata     SkillMetadata
+	Resources    map[string]string
+	// ZipPath is set for skills packaged as an archive.
+	ZipPath string
+	// Origin names where the skill was discovered: "local" for Registry.Root
+	// or the Name of the Source that provided it.
+	Origin string
+	// Revision identifies the version of the origin the skill was read from,
+	// such as the commit SHA of a GitSource.
+	Revision string
+	// MetadataLoadTime is the time spent discovering the skill and parsing its
+	// front matter; ContentLoadTime is the time spent loading the body and
+	// resources on demand in lazy mode.
+	MetadataLoadTime time.Duration
+	ContentLoadTime  time.Duration
+	src              Source
+	candidate        string
+	modTime          time.Time
+	directories      map[string]time.Time
+}
+
+type ResourceMetadata struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	MIMEType any    `json:"mime_type"`
+}
+
+type ResourceInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Embedded reports whether the skill was compiled into the binary.
+func (s Skill) Embedded() bool {
+	return s.Origin == embeddedOrigin
+}
+
+// IsLocal reports whether the skill was discovered under Registry.Root.
+func (s Skill) IsLocal() bool {
+	return s.Origin == localOrigin
+}
+
+func (s Skill) IsZip() bool {
+	return s.ZipPath != ""
+}
+
+func (s Skill) ResourceName(relPath string) string {
+	return filepath.ToSlash(s.Slug + "/" + relPath)
+}
@@ -0,0 +1,350 @@
+package skillz
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	usageToolCall     = "tool_call"
+	usageResourceRead = "resource_read"
+	// usageBrowse is a search_resources or list_skill_files call; it looks at
+	// a skill without invoking it.
+	usageBrowse = "browse"
+)
+
+// UsageEvent is one line of the usage log.
+type UsageEvent struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Tool     string    `json:"tool,omitempty"`
+	Skill    string    `json:"skill,omitempty"`
+	Resource string    `json:"resource,omitempty"`
+	Client   string    `json:"client,omitempty"`
+	Caller   string    `json:"caller,omitempty"`
+	Outcome  string    `json:"outcome"`
+	Bytes    int       `json:"bytes,omitempty"`
+}
+
+// UsageLog appends UsageEvents as JSON lines to a local file. Existing lines
+// are never rewritten, so the file can be rotated or shipped by external tools.
+type UsageLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	now     func() time.Time
+}
+
+func OpenUsageLog(path string) (*UsageLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageLog{file: file, encoder: json.NewEncoder(file), now: time.Now}, nil
+}
+
+func (u *UsageLog) Record(event UsageEvent) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if event.Time.IsZero() {
+		event.Time = u.now().UTC()
+	}
+	return u.encoder.Encode(event)
+}
+
+func (u *UsageLog) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.file.Close()
+}
+
+func (u *UsageLog) serverOptions(registry *Registry, options ServerOptions) []server.ServerOption {
+	toolMiddleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			event := UsageEvent{
+				Kind:    usageToolCall,
+				Tool:    request.Params.Name,
+				Client:  clientName(ctx),
+				Caller:  callerIdentity(ctx).Subject,
+				Outcome: "ok",
+			}
+			if err != nil || (result != nil && result.IsError) {
+				event.Outcome = "error"
+			}
+			switch call := options.classifyToolCall(registry, request); call.kind {
+			case fetchResourceCall:
+				event.Kind = usageResourceRead
+				event.Skill, event.Resource = call.skill, call.resource
+				event.Outcome, event.Bytes = fetchResultSummary(result, err)
+			case searchResourcesCall, listSkillFilesCall:
+				event.Kind = usageBrowse
+				event.Skill = call.skill
+			case skillToolCall:
+				event.Skill = call.skill
+			}
+			_ = u.Record(event)
+			return result, err
+		}
+	}
+	resourceMiddleware := func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			contents, err := next(ctx, request)
+			event := UsageEvent{
+				Kind:    usageResourceRead,
+				Tool:    "resources/read",
+				Client:  clientName(ctx),
+				Caller:  callerIdentity(ctx).Subject,
+				Outcome: "ok",
+				Bytes:   resourceContentBytes(contents),
+			}
+			read := classifyResourceRead(registry, request.Params.URI)
+			event.Skill, event.Resource = read.skill, read.resource
+			if err != nil {
+				event.Outcome = "error"
+			}
+			_ = u.Record(event)
+			return contents, err
+		}
+	}
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(toolMiddleware),
+		server.WithResourceHandlerMiddleware(resourceMiddleware),
+	}
+}
+
+func clientName(ctx context.Context) string {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return ""
+	}
+	info := session.GetClientInfo()
+	if info.Version == "" {
+		return info.Name
+	}
+	return info.Name + "/" + info.Version
+}
+
+// ReadUsageLog parses a usage log, skipping lines that are not valid events
+// such as a partially written final line.
+func ReadUsageLog(path string) ([]UsageEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := []UsageEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var event UsageEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+type SkillUsage struct {
+	Skill string `json:"skill"`
+	// ToolCalls counts invocations of the skill's tool.
+	ToolCalls     int `json:"tool_calls"`
+	ResourceReads int `json:"resource_reads"`
+	// Browses counts searches and file listings of the skill.
+	Browses  int            `json:"browses"`
+	Errors   int            `json:"errors"`
+	Bytes    int            `json:"bytes"`
+	Clients  map[string]int `json:"clients,omitempty"`
+	LastUsed time.Time      `json:"last_used"`
+}
+
+type ResourceUsage struct {
+	Skill    string `json:"skill"`
+	Resource string `json:"resource"`
+	Reads    int    `json:"reads"`
+	Bytes    int    `json:"bytes"`
+}
+
+type DayUsage struct {
+	Day           string `json:"day"`
+	ToolCalls     int    `json:"tool_calls"`
+	ResourceReads int    `json:"resource_reads"`
+	Browses       int    `json:"browses"`
+}
+
+// UsageReport summarizes a usage log against the currently loaded skills.
+type UsageReport struct {
+	Skills    []SkillUsage    `json:"skills"`
+	Resources []ResourceUsage `json:"resources"`
+	Days      []DayUsage      `json:"days"`
+	// NeverInvoked lists loaded skills with no recorded tool call.
+	NeverInvoked []string `json:"never_invoked"`
+}
+
+func SummarizeUsage(events []UsageEvent, skills []Skill) UsageReport {
+	bySkill := map[string]*SkillUsage{}
+	byResource := map[[2]string]*ResourceUsage{}
+	byDay := map[string]*DayUsage{}
+
+	for _, event := range events {
+		// Logs written before browsing had its own kind recorded it as tool
+		// calls.
+		if event.Kind == usageToolCall && (event.Tool == "search_resources" || event.Tool == "list_skill_files") {
+			event.Kind = usageBrowse
+		}
+		day := event.Time.UTC().Format(time.DateOnly)
+		dayUsage, ok := byDay[day]
+		if !ok {
+			dayUsage = &DayUsage{Day: day}
+			byDay[day] = dayUsage
+		}
+		switch event.Kind {
+		case usageResourceRead:
+			dayUsage.ResourceReads++
+		case usageBrowse:
+			dayUsage.Browses++
+		default:
+			dayUsage.ToolCalls++
+		}
+
+		if event.Skill == "" {
+			continue
+		}
+		skillUsage, ok := bySkill[event.Skill]
+		if !ok {
+			skillUsage = &SkillUsage{Skill: event.Skill, Clients: map[string]int{}}
+			bySkill[event.Skill] = skillUsage
+		}
+		switch event.Kind {
+		case usageResourceRead:
+			skillUsage.ResourceReads++
+		case usageBrowse:
+			skillUsage.Browses++
+		default:
+			skillUsage.ToolCalls++
+		}
+		if event.Outcome != "ok" {
+			skillUsage.Errors++
+		}
+		skillUsage.Bytes += event.Bytes
+		if event.Client != "" {
+			skillUsage.Clients[event.Client]++
+		}
+		if event.Time.After(skillUsage.LastUsed) {
+			skillUsage.LastUsed = event.Time
+		}
+
+		if event.Kind == usageResourceRead && event.Resource != "" && event.Outcome == "ok" {
+			key := [2]string{event.Skill, event.Resource}
+			resourceUsage, ok := byResource[key]
+			if !ok {
+				resourceUsage = &ResourceUsage{Skill: event.Skill, Resource: event.Resource}
+				byResource[key] = resourceUsage
+			}
+			resourceUsage.Reads++
+			resourceUsage.Bytes += event.Bytes
+		}
+	}
+
+	report := UsageReport{
+		Skills:       []SkillUsage{},
+		Resources:    []ResourceUsage{},
+		Days:         []DayUsage{},
+		NeverInvoked: []string{},
+	}
+	for _, usage := range bySkill {
+		report.Skills = append(report.Skills, *usage)
+	}
+	sort.Slice(report.Skills, func(i, j int) bool {
+		left, right := report.Skills[i], report.Skills[j]
+		if left.activity() != right.activity() {
+			return left.activity() > right.activity()
+		}
+		return left.Skill < right.Skill
+	})
+	for _, usage := range byResource {
+		report.Resources = append(report.Resources, *usage)
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		left, right := report.Resources[i], report.Resources[j]
+		if left.Reads != right.Reads {
+			return left.Reads > right.Reads
+		}
+		if left.Skill != right.Skill {
+			return left.Skill < right.Skill
+		}
+		return left.Resource < right.Resource
+	})
+	for _, usage := range byDay {
+		report.Days = append(report.Days, *usage)
+	}
+	sort.Slice(report.Days, func(i, j int) bool { return report.Days[i].Day < report.Days[j].Day })
+	for _, skill := range skills {
+		if usage, ok := bySkill[skill.Slug]; !ok || usage.ToolCalls == 0 {
+			report.NeverInvoked = append(report.NeverInvoked, skill.Slug)
+		}
+	}
+	return report
+}
+
+func (u SkillUsage) activity() int {
+	return u.ToolCalls + u.ResourceReads + u.Browses
+}
+
+// Render writes the report as plain text tables.
+func (r UsageReport) Render(w io.Writer) {
+	fmt.Fprintln(w, "Skills:")
+	if len(r.Skills) == 0 {
+		fmt.Fprintln(w, "  (no usage recorded)")
+	}
+	for _, usage := range r.Skills {
+		fmt.Fprintf(w, "  %-32s calls=%d reads=%d browses=%d errors=%d bytes=%d last=%s", usage.Skill, usage.ToolCalls, usage.ResourceReads, usage.Browses, usage.Errors, usage.Bytes, usage.LastUsed.UTC().Format(time.RFC3339))
+		if len(usage.Clients) > 0 {
+			fmt.Fprintf(w, " clients=%s", formatClientCounts(usage.Clients))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "\nResources:")
+	if len(r.Resources) == 0 {
+		fmt.Fprintln(w, "  (no resource reads recorded)")
+	}
+	for _, usage := range r.Resources {
+		fmt.Fprintf(w, "  %-48s reads=%d bytes=%d\n", BuildResourceURI(Skill{Slug: usage.Skill}, usage.Resource), usage.Reads, usage.Bytes)
+	}
+
+	fmt.Fprintln(w, "\nDays:")
+	for _, usage := range r.Days {
+		fmt.Fprintf(w, "  %s calls=%d reads=%d browses=%d\n", usage.Day, usage.ToolCalls, usage.ResourceReads, usage.Browses)
+	}
+
+	fmt.Fprintln(w, "\nNever invoked:")
+	if len(r.NeverInvoked) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, slug := range r.NeverInvoked {
+		fmt.Fprintf(w, "  %s\n", slug)
+	}
+}
+
+func formatClientCounts(clients map[string]int) string {
+	parts := make([]string, 0, len(clients))
+	for name, count := range clients {
+		parts = append(parts, fmt.Sprintf("%s:%d", name, count))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
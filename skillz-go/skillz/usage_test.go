@@ -0,0 +1,135 @@
+package skillz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsageLogRecordsCallsAndReads(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	writeSkill(t, temp, "idle")
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	logPath := filepath.Join(t.TempDir(), "usage.jsonl")
+	usageLog, err := OpenUsageLog(logPath)
+	if err != nil {
+		t.Fatalf("open usage log: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{Usage: usageLog})
+
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "testskill",
+		"arguments": map[string]any{"task": "go"},
+	})
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "fetch_resource",
+		"arguments": map[string]any{"resource_uri": "resource://skillz/testskill/script.py"},
+	})
+	callServer(t, mcpServer, "resources/read", map[string]any{"uri": "resource://skillz/testskill/script.py"})
+	if err := usageLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := ReadUsageLog(logPath)
+	if err != nil {
+		t.Fatalf("read usage log: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[1].Kind != usageResourceRead || events[1].Resource != "script.py" || events[1].Bytes != 14 {
+		t.Fatalf("unexpected fetch event: %+v", events[1])
+	}
+
+	report := SummarizeUsage(events, registry.Skills())
+	if len(report.Skills) != 1 || report.Skills[0].ToolCalls != 1 || report.Skills[0].ResourceReads != 2 {
+		t.Fatalf("unexpected skill usage: %+v", report.Skills)
+	}
+	if len(report.Resources) != 1 || report.Resources[0].Reads != 2 || report.Resources[0].Bytes != 28 {
+		t.Fatalf("unexpected resource usage: %+v", report.Resources)
+	}
+	if len(report.NeverInvoked) != 1 || report.NeverInvoked[0] != "idle" {
+		t.Fatalf("unexpected never invoked: %v", report.NeverInvoked)
+	}
+}
+
+func TestUsageSeparatesBrowsingFromInvocations(t *testing.T) {
+	temp := t.TempDir()
+	writeSkillWithResources(t, temp)
+	registry := NewRegistry(temp)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	logPath := filepath.Join(t.TempDir(), "usage.jsonl")
+	usageLog, err := OpenUsageLog(logPath)
+	if err != nil {
+		t.Fatalf("open usage log: %v", err)
+	}
+	mcpServer := BuildMCPServer(registry, ServerOptions{Usage: usageLog})
+
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "search_resources",
+		"arguments": map[string]any{"skill": "testskill", "pattern": "hello"},
+	})
+	callServer(t, mcpServer, "tools/call", map[string]any{
+		"name":      "list_skill_files",
+		"arguments": map[string]any{"slug": "testskill"},
+	})
+	if err := usageLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadUsageLog(logPath)
+	if err != nil {
+		t.Fatalf("read usage log: %v", err)
+	}
+	// Logs from before browsing had its own kind count the same way.
+	events = append(events, UsageEvent{Kind: usageToolCall, Tool: "search_resources", Skill: "testskill", Outcome: "ok"})
+
+	report := SummarizeUsage(events, registry.Skills())
+	if len(report.Skills) != 1 || report.Skills[0].Browses != 3 || report.Skills[0].ToolCalls != 0 {
+		t.Fatalf("unexpected skill usage: %+v", report.Skills)
+	}
+	if len(report.NeverInvoked) != 1 || report.NeverInvoked[0] != "testskill" {
+		t.Fatalf("expected a browsed skill to count as never invoked, got %v", report.NeverInvoked)
+	}
+}
+
+func TestSummarizeUsageGroupsByDay(t *testing.T) {
+	day := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	events := []UsageEvent{
+		{Time: day, Kind: usageToolCall, Skill: "a", Outcome: "ok", Client: "cli/1.0"},
+		{Time: day.Add(2 * time.Hour), Kind: usageToolCall, Skill: "a", Outcome: "error"},
+		{Time: day.Add(3 * time.Hour), Kind: usageResourceRead, Skill: "a", Resource: "x.md", Outcome: "ok"},
+	}
+	report := SummarizeUsage(events, nil)
+	if len(report.Days) != 2 || report.Days[0].Day != "2024-03-01" || report.Days[1].ToolCalls != 1 || report.Days[1].ResourceReads != 1 {
+		t.Fatalf("unexpected days: %+v", report.Days)
+	}
+	if report.Skills[0].Errors != 1 || report.Skills[0].Clients["cli/1.0"] != 1 {
+		t.Fatalf("unexpected skill usage: %+v", report.Skills[0])
+	}
+
+	var output strings.Builder
+	report.Render(&output)
+	if !strings.Contains(output.String(), "resource://skillz/a/x.md") {
+		t.Fatalf("render missing resource:\n%s", output.String())
+	}
+}
+
+func TestReadUsageLogSkipsPartialLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	content := `{"time":"2024-03-01T00:00:00Z","kind":"tool_call","skill":"a","outcome":"ok"}` + "\n" + `{"time":"2024-03`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadUsageLog(path)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one event, got %v, %v", events, err)
+	}
+}
@@ -1,7 +1,9 @@
 package skillz
 
 import (
+	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -33,3 +35,25 @@ func normalizeRelPath(relPath string) string {
 	normalized = strings.TrimPrefix(normalized, "./")
 	return normalized
 }
+
+// writeFileAtomic replaces path with data through a temporary file and a
+// rename, so concurrent readers, in this process or another, never see a
+// partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}